@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The advisor shows the human the numbers behind a decision before they make
+// it: what calling costs against the pot, the cards that complete a draw, and
+// roughly how often the hand wins against the hands still in.
+
+// Deals the advisor plays out to estimate equity
+const advisorTrials = 2000
+
+// plausibleRange weights the hand buckets by how likely a player still in the
+// hand is to hold one: pairs and better always, draws often before the draw,
+// and junk now and then as a bluff
+func plausibleRange(round string) []float64 {
+	draws := 0.6
+	if round == postDraw {
+		draws = 0.15 // Draws have missed by now, and only bluff
+	}
+	weights := make([]float64, len(bucketNames))
+	for b := range weights {
+		switch {
+		case b >= bucketPairOfTwos:
+			weights[b] = 1
+		case b >= 2: // Four to a straight or a flush
+			weights[b] = draws
+		default:
+			weights[b] = 0.15
+		}
+	}
+	return weights
+}
+
+// Cards a seat hasn't seen: the whole deck, jokers and all, less their hand
+func (g *game) unseenBy(seat int) deck {
+	return unseen(g.players[seat].hand, g.wilds.jokers)
+}
+
+func unseen(hand deck, jokers int) deck {
+	held := make(map[string]int)
+	for _, c := range hand {
+		held[c]++
+	}
+	var rest deck
+	for _, c := range newDeckWithJokers(jokers) {
+		if held[c] > 0 {
+			held[c]--
+			continue
+		}
+		rest = append(rest, c)
+	}
+	return rest
+}
+
+// outs counts the unseen cards that make a straight or better when drawn in
+// place of one card, by the hand each makes
+func (g *game) outs(hand deck, discard int, unseen deck) map[string]int {
+	outs := make(map[string]int)
+	drawn := make(deck, len(hand))
+	copy(drawn, hand)
+	for _, c := range unseen {
+		drawn[discard] = c
+		if rank := g.evaluate(drawn); rank.rank >= 5 {
+			outs[rank.rankName]++
+		}
+	}
+	return outs
+}
+
+// advisedDraw is the draw the advisor gives the odds for: the one card that
+// leaves the most outs when a hand has nothing yet, or the standard discards,
+// with their outs when they throw just one card
+func (g *game) advisedDraw(hand deck, unseen deck) ([]int, map[string]int) {
+	if g.evaluate(hand).rank == 1 {
+		best, bestOuts := 0, map[string]int{}
+		for pos := range hand {
+			if outs := g.outs(hand, pos, unseen); sumOuts(outs) > sumOuts(bestOuts) {
+				best, bestOuts = pos, outs
+			}
+		}
+		if sumOuts(bestOuts) >= 4 {
+			return []int{best}, bestOuts
+		}
+	}
+	discards := standardDiscards(hand, g.wilds)
+	if len(discards) == 1 {
+		return discards, g.outs(hand, discards[0], unseen)
+	}
+	return discards, nil
+}
+
+// improveChance estimates how often drawing to a hand makes it a better kind
+// of hand, such as a pair drawing three becoming two pair or trips
+func (g *game) improveChance(hand deck, discards []int, unseen deck, trials int, rng *rand.Rand) float64 {
+	before := g.evaluate(hand).rank
+	stub := make(deck, len(unseen))
+	drawn := make(deck, len(hand))
+	improved := 0
+	for i := 0; i < trials; i++ {
+		copy(stub, unseen)
+		copy(drawn, hand)
+		rng.Shuffle(len(stub), func(i, j int) {
+			stub[i], stub[j] = stub[j], stub[i]
+		})
+		for k, pos := range discards {
+			drawn[pos] = stub[k]
+		}
+		if g.evaluate(drawn).rank > before {
+			improved++
+		}
+	}
+	return float64(improved) / float64(trials)
+}
+
+// advisorEquity estimates the share of the pot a seat wins at the showdown
+// against everyone else still in, each holding a hand from the range
+func (g *game) advisorEquity(seat int, weights []float64, trials int, rng *rand.Rand) float64 {
+	return g.spotAt(seat).equity(weights, trials, rng)
+}
+
+// advice puts the numbers into a sentence
+func advice(toCall, pot int, equity float64) string {
+	if toCall == 0 {
+		if equity >= 0.5 {
+			return fmt.Sprintf("Checking is free; you have ~%.0f%%, so betting for value is worth a thought.", 100*equity)
+		}
+		return fmt.Sprintf("Checking is free; you have ~%.0f%%, so there's no need to put more in.", 100*equity)
+	}
+	need := float64(toCall) / float64(pot+toCall)
+	switch {
+	case equity < need:
+		return fmt.Sprintf("Calling needs %.0f%% equity; you have ~%.0f%%, so folding loses least.", 100*need, 100*equity)
+	case equity >= 0.5:
+		return fmt.Sprintf("Calling needs %.0f%% equity; you have ~%.0f%%, so raising for value is worth a thought.", 100*need, 100*equity)
+	}
+	return fmt.Sprintf("Calling needs %.0f%% equity; you have ~%.0f%%, so calling pays.", 100*need, 100*equity)
+}
+
+// showAdvice prints the advisor's panel for a seat about to act
+func (g *game) showAdvice(seat int) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if g.rng != nil {
+		rng = rand.New(rand.NewSource(g.rng.Int63())) // a seeded game plays out the same
+	}
+	p := g.players[seat]
+	toCall := g.toCall(seat)
+
+	fmt.Fprintln(g.out, "\n=== Advisor ===")
+	if toCall > 0 {
+		fmt.Fprintf(g.out, "To call: %d into a pot of %d, odds of %.1f to 1\n", toCall, g.pot, float64(g.pot)/float64(toCall))
+	} else {
+		fmt.Fprintln(g.out, "To call: nothing, you can check")
+	}
+
+	if g.round == preDraw {
+		unseen := g.unseenBy(seat)
+		discards, outs := g.advisedDraw(p.hand, unseen)
+		switch {
+		case len(discards) == 0:
+			fmt.Fprintf(g.out, "Draw: stand pat with %s\n", g.evaluate(p.hand).rankName)
+		case len(outs) > 0:
+			fmt.Fprintf(g.out, "Draw: throw the %s, %s (%.0f%% to hit)\n", p.hand[discards[0]], describeOuts(outs, len(unseen)), 100*float64(sumOuts(outs))/float64(len(unseen)))
+		default:
+			chance := g.improveChance(p.hand, discards, unseen, advisorTrials, rng)
+			fmt.Fprintf(g.out, "Draw: throw %d, which improves %s %.0f%% of the time\n", len(discards), g.evaluate(p.hand).rankName, 100*chance)
+		}
+	}
+
+	opponents := "1 opponent"
+	if n := g.activeCount() - 1; n != 1 {
+		opponents = fmt.Sprintf("%d opponents", n)
+	}
+	equity := g.advisorEquity(seat, plausibleRange(g.round), advisorTrials, rng)
+	fmt.Fprintf(g.out, "Equity: ~%.0f%% against %s playing pairs, draws and the odd bluff\n", 100*equity, opponents)
+	fmt.Fprintln(g.out, advice(toCall, g.pot, equity))
+}
+
+func sumOuts(outs map[string]int) int {
+	total := 0
+	for _, n := range outs {
+		total += n
+	}
+	return total
+}
+
+// Describe outs, such as "12 outs of 47: 9 to a Flush, 3 to a Straight"
+func describeOuts(outs map[string]int, unseen int) string {
+	names := make([]string, 0, len(outs))
+	for name := range outs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if outs[names[i]] != outs[names[j]] {
+			return outs[names[i]] > outs[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d to a %s", outs[name], name)
+	}
+	return fmt.Sprintf("%d outs of %d: %s", sumOuts(outs), unseen, strings.Join(parts, ", "))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestOutsForCommonDraws(t *testing.T) {
+	g := newGame()
+	tests := []struct {
+		hand deck
+		want map[string]int
+	}{
+		{deck{"Ace of Spades", "King of Spades", "Seven of Spades", "Two of Spades", "Nine of Hearts"}, map[string]int{"Flush": 9}},
+		{deck{"Five of Hearts", "Six of Diamonds", "Seven of Clubs", "Eight of Spades", "King of Diamonds"}, map[string]int{"Straight": 8}},
+		{deck{"Five of Hearts", "Six of Diamonds", "Eight of Clubs", "Nine of Spades", "King of Diamonds"}, map[string]int{"Straight": 4}},
+		{deck{"Nine of Hearts", "Nine of Diamonds", "Four of Clubs", "Four of Spades", "King of Diamonds"}, map[string]int{"Full House": 4}},
+		{deck{"Five of Spades", "Six of Spades", "Seven of Spades", "Eight of Spades", "King of Diamonds"}, map[string]int{"Straight Flush": 2, "Flush": 7, "Straight": 6}},
+	}
+	for _, test := range tests {
+		g.players[0].hand = test.hand
+		discards, outs := g.advisedDraw(test.hand, g.unseenBy(0))
+		if len(discards) != 1 || discards[0] != 4 {
+			t.Errorf("Expected %v to throw the last card, but it throws %v", test.hand, discards)
+		}
+		if describeOuts(outs, 47) != describeOuts(test.want, 47) {
+			t.Errorf("Expected %v to have %s, but it has %s", test.hand, describeOuts(test.want, 47), describeOuts(outs, 47))
+		}
+	}
+}
+
+func TestAdvice(t *testing.T) {
+	tests := []struct {
+		toCall, pot int
+		equity      float64
+		want        string
+	}{
+		{50, 150, 0.32, "Calling needs 25% equity; you have ~32%, so calling pays."},
+		{100, 100, 0.32, "Calling needs 50% equity; you have ~32%, so folding loses least."},
+		{50, 150, 0.7, "Calling needs 25% equity; you have ~70%, so raising for value is worth a thought."},
+		{0, 100, 0.2, "Checking is free; you have ~20%, so there's no need to put more in."},
+	}
+	for _, test := range tests {
+		if got := advice(test.toCall, test.pot, test.equity); got != test.want {
+			t.Errorf("Expected %q, but got %q", test.want, got)
+		}
+	}
+}
+
+func TestAdvisorEquity(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	g := newGame()
+	g.players[0].hand = deck{"Ace of Spades", "Ace of Hearts", "Ace of Diamonds", "King of Clubs", "Seven of Hearts"}
+	if eq := g.advisorEquity(0, plausibleRange(preDraw), 1000, rng); eq < 0.75 {
+		t.Errorf("Expected trip Aces to win most of the time heads-up, but got %v", eq)
+	}
+	g.players[0].hand = deck{"Two of Spades", "Four of Hearts", "Seven of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	if eq := g.advisorEquity(0, plausibleRange(postDraw), 1000, rng); eq > 0.3 {
+		t.Errorf("Expected Jack-high after the draw to lose most of the time, but got %v", eq)
+	}
+
+	// Seven opponents drawing can use up the deck
+	g.addOpponents(7)
+	g.players[0].hand = deck{"Two of Spades", "Four of Hearts", "Seven of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	if eq := g.advisorEquity(0, plausibleRange(preDraw), 200, rng); eq > 0.15 {
+		t.Errorf("Expected junk to do badly against seven hands, but got %v", eq)
+	}
+}
+
+func TestShowAdvice(t *testing.T) {
+	var out bytes.Buffer
+	g := newGame()
+	g.out = &out
+	g.postBlinds()
+	g.dealHands()
+	g.players[0].hand = deck{"Ace of Spades", "King of Spades", "Seven of Spades", "Two of Spades", "Nine of Hearts"}
+	out.Reset()
+
+	g.showAdvice(0)
+	for _, want := range []string{"=== Advisor ===", "To call: 25 into a pot of 75, odds of 3.0 to 1", "throw the Nine of Hearts, 9 outs of 47: 9 to a Flush (19% to hit)", "against 1 opponent", "Calling needs 25% equity"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the advice to include %q, but got:\n%s", want, out.String())
+		}
+	}
+}
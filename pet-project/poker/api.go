@@ -0,0 +1,637 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lobby serves the HTTP API: JSON endpoints to create, list and join tables and
+// to act at them, and a WebSocket feed of each table's events.
+//
+//	GET    /api/tables               list the tables
+//	POST   /api/tables               create one: {"name", "bots", "stack", "limit"}
+//	GET    /api/tables/{id}          the table as you see it
+//	POST   /api/tables/{id}/players  take a seat: {"name"}, answered with your token
+//	DELETE /api/tables/{id}/players  leave your seat
+//	POST   /api/tables/{id}/actions  act: {"action": "fold", "check", "call", "bet",
+//	                                 "raise" or "draw", "amount", "discards"}
+//	POST   /api/tables/{id}/entropy  mix {"entropy"} into the next shuffle
+//	GET    /api/tables/{id}/events   WebSocket feed of the table's events
+//
+// Players send their token as "Authorization: Bearer <token>", or as a token
+// query parameter where headers can't be set, as when opening a WebSocket. Each
+// player sees only their own cards. A feed opened without a token spectates,
+// and with ?commentator=true it sees every card, commentaryDelay late.
+//
+// A player whose last event feed closes keeps their seat for the grace period,
+// and opening a feed with their token again brings them back.
+type lobby struct {
+	mu       sync.Mutex
+	tables   map[string]*apiTable
+	order    []string // table IDs in the order they were made
+	nextID   int
+	pause    time.Duration // between hands
+	timeout  time.Duration // to act, for every table
+	timeBank time.Duration
+	console  io.Writer
+
+	commentaryDelay time.Duration // 0 when commentators aren't allowed
+	grace           time.Duration // a dropped player's seat is kept this long
+
+	consoleMu sync.Mutex // shared by every table's log
+}
+
+// apiTable is a table run by a server, with the players who joined over HTTP
+type apiTable struct {
+	id      string
+	name    string
+	stack   int
+	s       *server
+	players map[string]*webPlayer // by token, guarded by s.mu
+}
+
+// webPlayer is a player at the table through the API. Their messages go to
+// whichever of their event feeds are open.
+type webPlayer struct {
+	*remoteSeat
+	feeds map[*wsFeed]bool
+}
+
+func newLobby() *lobby {
+	return &lobby{
+		tables:   make(map[string]*apiTable),
+		pause:    3 * time.Second,
+		timeout:  30 * time.Second,
+		timeBank: time.Minute,
+		grace:    time.Minute,
+		console:  os.Stdout,
+	}
+}
+
+func (l *lobby) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tables", l.routeLobby)
+	mux.HandleFunc("/api/tables/", l.routeTable)
+	return mux
+}
+
+// tableHandler serves one of a table's endpoints
+type tableHandler func(*lobby, http.ResponseWriter, *http.Request, *apiTable)
+
+// A table's endpoints, by the path after its id and then by method. The
+// routing is done by hand rather than with method and wildcard patterns, so
+// it doesn't depend on the Go version the package is built for.
+var tableRoutes = map[string]map[string]tableHandler{
+	"": {http.MethodGet: (*lobby).showTable},
+	"players": {
+		http.MethodPost:   (*lobby).joinTable,
+		http.MethodDelete: (*lobby).leaveTable,
+	},
+	"actions": {http.MethodPost: (*lobby).submitAction},
+	"entropy": {http.MethodPost: (*lobby).addEntropy},
+	"events":  {http.MethodGet: (*lobby).streamEvents},
+}
+
+// routeLobby serves /api/tables itself
+func (l *lobby) routeLobby(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		l.listTables(w, r)
+	case http.MethodPost:
+		l.createTable(w, r)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// routeTable looks up the table named in the path and hands the request to
+// the endpoint for the rest of it
+func (l *lobby) routeTable(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/tables/"), "/")
+	methods := tableRoutes[rest]
+	if id == "" || methods == nil {
+		writeError(w, http.StatusNotFound, errors.New("no such endpoint"))
+		return
+	}
+	handle := methods[r.Method]
+	if handle == nil {
+		var allowed []string
+		for method := range methods {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		methodNotAllowed(w, allowed...)
+		return
+	}
+
+	l.mu.Lock()
+	t := l.tables[id]
+	l.mu.Unlock()
+	if t == nil {
+		writeError(w, http.StatusNotFound, errors.New("no such table"))
+		return
+	}
+	handle(l, w, r, t)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+// tableView is a table as one player sees it
+type tableView struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	Betting string     `json:"betting"`
+	Blinds  string     `json:"blinds"`
+	Round   string     `json:"round"`
+	Pot     int        `json:"pot"`
+	Dealer  int        `json:"dealer"`
+	Shown   bool       `json:"shown"`          // hands have been shown down
+	Turn    string     `json:"turn,omitempty"` // the player the table is waiting on
+	Players []seatView `json:"players"`
+	You     *yourView  `json:"you,omitempty"`
+}
+
+type seatView struct {
+	Name   string   `json:"name"`
+	Chips  int      `json:"chips"`
+	Bet    int      `json:"bet"`
+	Status string   `json:"status"`          // in, folded or out
+	Away   bool     `json:"away,omitempty"`  // their connection is down
+	Cards  []string `json:"cards,omitempty"` // your own, and any shown down
+}
+
+type yourView struct {
+	Name     string   `json:"name"`
+	Seat     int      `json:"seat"` // -1 until you are dealt in
+	Hand     []string `json:"hand,omitempty"`
+	HandRank string   `json:"handRank,omitempty"`
+	Turn     string   `json:"turn,omitempty"` // "action" or "draw" when it's your turn
+	ToCall   int      `json:"toCall"`
+	MinRaise int      `json:"minRaise"` // 0 when you can't raise
+	MaxRaise int      `json:"maxRaise"`
+	MaxDraw  int      `json:"maxDraw"`
+
+	Deadline *time.Time `json:"deadline,omitempty"` // when your turn runs out
+	TimeBank float64    `json:"timeBank"`           // seconds left in your time bank
+}
+
+// tableSummary is a table as listed in the lobby
+type tableSummary struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Betting string   `json:"betting"`
+	Stack   int      `json:"stack"`
+	Players []string `json:"players"`
+}
+
+// apiEvent is one message of a WebSocket feed: the protocol message it stands
+// for, such as "info" or "action", and the table as it is now
+type apiEvent struct {
+	Type string    `json:"type"`
+	Text string    `json:"text,omitempty"`
+	View tableView `json:"view"`
+}
+
+// view shows the table to a player, or to someone without a seat when the
+// player is nil. Everyone sees the hands shown down, and commentators see all
+// of them. Callers hold s.mu.
+func (t *apiTable) view(viewer *webPlayer, everything bool) tableView {
+	g := t.s.game
+	v := tableView{
+		ID:      t.id,
+		Name:    t.name,
+		Betting: g.betting.structure.String(),
+		Blinds:  fmt.Sprintf("%d/%d", g.smallBlind, g.bigBlind),
+		Round:   g.round,
+		Pot:     g.pot,
+		Dealer:  g.dealer,
+		Shown:   g.revealed,
+		Turn:    t.s.turn(),
+		Players: []seatView{},
+	}
+
+	seat := -1
+	if viewer != nil {
+		seat = viewer.seat()
+	}
+	for i, p := range g.players {
+		sv := seatView{Name: p.name, Chips: p.chips, Bet: p.bet, Status: seatStatus(p)}
+		if r, remote := p.strategy.(*remoteSeat); remote {
+			sv.Away = r.away()
+		}
+		if i == seat || everything || (g.revealed && !p.folded) {
+			sv.Cards = p.hand
+		}
+		v.Players = append(v.Players, sv)
+	}
+
+	if viewer == nil || viewer.name == "" {
+		return v
+	}
+	you := &yourView{Name: viewer.name, Seat: seat, MaxDraw: maxDraw}
+	if seat >= 0 {
+		p := g.players[seat]
+		you.Hand = p.hand
+		if len(p.hand) > 0 {
+			you.HandRank = g.evaluate(p.hand).rankName
+		}
+		you.ToCall = g.toCall(seat)
+		you.TimeBank = g.bankLeft(seat).Seconds()
+		if minRaise, maxRaise, err := g.raiseBounds(seat); err == nil {
+			you.MinRaise, you.MaxRaise = minRaise, maxRaise
+		}
+	}
+	you.Turn = strings.ToLower(viewer.waiting)
+	if you.Turn != "" && !viewer.deadline.IsZero() {
+		you.Deadline = &viewer.deadline
+	}
+	v.You = you
+	return v
+}
+
+// Send a message to each of the player's feeds. Callers hold s.mu.
+func (wp *webPlayer) sendFeeds(line string) {
+	for f := range wp.feeds {
+		f.send(line)
+	}
+}
+
+func (l *lobby) listTables(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	tables := make([]*apiTable, len(l.order))
+	for i, id := range l.order {
+		tables[i] = l.tables[id]
+	}
+	l.mu.Unlock()
+
+	summaries := []tableSummary{}
+	for _, t := range tables {
+		t.s.mu.Lock()
+		summary := tableSummary{ID: t.id, Name: t.name, Betting: t.s.game.betting.structure.String(), Stack: t.stack, Players: []string{}}
+		for _, p := range t.s.game.players {
+			summary.Players = append(summary.Players, p.name)
+		}
+		for _, p := range t.s.joining {
+			summary.Players = append(summary.Players, p.name)
+		}
+		t.s.mu.Unlock()
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (l *lobby) createTable(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Name  string `json:"name"`
+		Bots  int    `json:"bots"`
+		Stack int    `json:"stack"`
+		Limit string `json:"limit"`
+	}{Bots: 1, Stack: 1000, Limit: "no-limit"}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	structure, err := parseBettingStructure(req.Limit)
+	switch {
+	case err != nil:
+	case req.Bots < 0 || req.Bots >= maxSeats:
+		err = fmt.Errorf("bots must be between 0 and %d", maxSeats-1)
+	case req.Stack <= 0:
+		err = errors.New("stack must be more than 0")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	l.mu.Lock()
+	l.nextID++
+	id := strconv.Itoa(l.nextID)
+	if req.Name == "" {
+		req.Name = "Table " + id
+	}
+	g := newGame()
+	g.betting.structure = structure
+	g.timeout = l.timeout
+	g.timeBank = l.timeBank
+	t := &apiTable{id: id, name: req.Name, stack: req.Stack, s: newServer(g, req.Stack), players: make(map[string]*webPlayer)}
+	t.s.pause = l.pause
+	t.s.commentaryDelay = l.commentaryDelay
+	t.s.grace = l.grace
+	t.s.console = &lineWriter{mu: &l.consoleMu, prefix: req.Name, out: l.console}
+	t.s.addBots(req.Bots)
+	l.tables[id] = t
+	l.order = append(l.order, id)
+	l.mu.Unlock()
+
+	go t.s.run()
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, t.view(nil, false))
+}
+
+// The player whose token came with the request, or nil. Callers hold s.mu.
+func (t *apiTable) playerFor(r *http.Request) *webPlayer {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return t.players[token]
+}
+
+func (l *lobby) showTable(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	writeJSON(w, http.StatusOK, t.view(t.playerFor(r), false))
+}
+
+func (l *lobby) joinTable(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	wp := &webPlayer{feeds: make(map[*wsFeed]bool)}
+	wp.remoteSeat = newRemoteSeat(t.s, wp.sendFeeds)
+	if err := wp.sitDown(req.Name); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	t.players[wp.token] = wp
+	fmt.Fprintf(t.s.console, "%s joins from %s\n", wp.name, r.RemoteAddr)
+
+	writeJSON(w, http.StatusCreated, struct {
+		Token string    `json:"token"`
+		View  tableView `json:"view"`
+	}{wp.token, t.view(wp, false)})
+}
+
+func (l *lobby) addEntropy(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	var req struct {
+		Entropy string `json:"entropy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if err := t.s.game.fair.addEntropy(req.Entropy); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (l *lobby) leaveTable(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	wp := t.playerFor(r)
+	if wp == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("unknown player token"))
+		return
+	}
+	wp.leave()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// submitAction checks an action against the game while the engine waits for
+// it, so a bad one can be refused straight away, then hands it to the engine
+func (l *lobby) submitAction(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	var req struct {
+		Action   string `json:"action"`
+		Amount   int    `json:"amount"`
+		Discards []int  `json:"discards"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	wp := t.playerFor(r)
+	if wp == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("unknown player token"))
+		return
+	}
+
+	kind, line := "ACTION", strings.ToUpper(req.Action)
+	switch line {
+	case "FOLD", "CHECK", "CALL":
+	case "BET", "RAISE":
+		line += " " + strconv.Itoa(req.Amount)
+	case "DRAW":
+		kind = "DRAW"
+		if len(req.Discards) > maxDraw {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("you can draw at most %d cards", maxDraw))
+			return
+		}
+		for _, pos := range req.Discards {
+			if pos < 1 || pos > 5 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("card %d is not in your hand", pos))
+				return
+			}
+			line += " " + strconv.Itoa(pos)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown action %q", req.Action))
+		return
+	}
+
+	if kind == "ACTION" && wp.waiting == kind {
+		d, err := parseAction(line)
+		if err == nil {
+			err = t.s.game.validate(wp.seat(), d)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := wp.reply(kind, line); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// wsFeed is one open WebSocket, following a table as a player, a spectator
+// or a commentator
+type wsFeed struct {
+	t      *apiTable
+	viewer *webPlayer    // nil without a seat
+	lag    time.Duration // how far behind a commentator's feed runs
+	ws     *wsConn
+	out    chan wsFrame
+}
+
+// Turn a protocol message into an event for the feed. Callers hold s.mu.
+func (f *wsFeed) send(line string) {
+	command, rest, _ := strings.Cut(line, " ")
+	event := apiEvent{Type: strings.ToLower(command), View: f.t.view(f.viewer, f.lag > 0)}
+	if command == "INFO" || command == "ERROR" || command == "CARDS" {
+		event.Text = rest
+	}
+	data, _ := json.Marshal(event)
+
+	due := time.Now().Add(f.lag)
+	f.queue(wsFrame{wsText, data, due})
+	if command == "BYE" {
+		f.queue(wsFrame{wsClose, nil, due})
+	}
+}
+
+// Queue a frame, dropping a feed too slow to keep up
+func (f *wsFeed) queue(frame wsFrame) {
+	select {
+	case f.out <- frame:
+	default:
+		f.ws.close()
+	}
+}
+
+func (l *lobby) streamEvents(w http.ResponseWriter, r *http.Request, t *apiTable) {
+	t.s.mu.Lock()
+	viewer := t.playerFor(r)
+	lag := t.s.commentaryDelay
+	t.s.mu.Unlock()
+
+	commentator, _ := strconv.ParseBool(r.URL.Query().Get("commentator"))
+	switch {
+	case commentator && lag <= 0:
+		writeError(w, http.StatusForbidden, errors.New("commentary is off at this table"))
+		return
+	case commentator && viewer != nil:
+		writeError(w, http.StatusForbidden, errors.New("players can't commentate"))
+		return
+	case !commentator:
+		lag = 0
+	}
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	f := &wsFeed{t: t, viewer: viewer, lag: lag, ws: ws, out: make(chan wsFrame, 4096)}
+	done := make(chan struct{})
+	go func() {
+		defer ws.close()
+		for {
+			select {
+			case frame := <-f.out:
+				select {
+				case <-time.After(time.Until(frame.due)):
+				case <-done:
+					return
+				}
+				if ws.writeFrame(frame.opcode, frame.payload) != nil || frame.opcode == wsClose {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	t.s.mu.Lock()
+	if commentator {
+		t.s.commentators[f] = true
+	} else {
+		t.s.listeners[f] = true
+	}
+	if viewer != nil {
+		viewer.feeds[f] = true
+		if viewer.away() {
+			viewer.reconnect(viewer.sendFeeds, nil)
+		}
+	}
+	f.send(t.s.stateLine())
+	if viewer != nil && viewer.waiting != "" {
+		f.send(viewer.pendingPrompt()) // Asked before the feed opened
+	}
+	t.s.mu.Unlock()
+
+	// Nothing is read from the feed but pings and the close
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil || opcode == wsClose {
+			break
+		}
+		if opcode == wsPing {
+			f.queue(wsFrame{wsPong, payload, time.Time{}})
+		}
+	}
+
+	t.s.mu.Lock()
+	delete(t.s.listeners, f)
+	delete(t.s.commentators, f)
+	if viewer != nil {
+		delete(viewer.feeds, f)
+		if len(viewer.feeds) == 0 && viewer.name != "" {
+			viewer.drop()
+		}
+	}
+	t.s.mu.Unlock()
+	close(done)
+}
+
+// newToken makes a random token that identifies a seated player
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// runAPI is the api subcommand: it serves the HTTP API for browser frontends
+func runAPI(args []string) error {
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	pause := flags.Duration("pause", 3*time.Second, "pause between hands")
+	timeout := flags.Duration("timeout", 30*time.Second, "time to act before checking or folding automatically (0 for no clock)")
+	timeBank := flags.Duration("time-bank", time.Minute, "extra time each player can use over the session")
+	commentaryDelay := flags.Duration("commentary-delay", 0, "let event feeds commentate, seeing every card this long after the play (0 for no commentary)")
+	grace := flags.Duration("grace", time.Minute, "how long the seat of a player whose event feeds all close is kept for them (0 to give it up at once)")
+	flags.Parse(args)
+
+	l := newLobby()
+	l.commentaryDelay = *commentaryDelay
+	l.grace = *grace
+	l.pause = *pause
+	l.timeout = *timeout
+	l.timeBank = *timeBank
+	fmt.Printf("Poker API listening on %s\n", *addr)
+	return http.ListenAndServe(*addr, l.handler())
+}
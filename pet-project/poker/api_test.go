@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestLobby serves the API with no pause between hands
+func startTestLobby(t *testing.T) *httptest.Server {
+	l := newLobby()
+	l.pause = 0
+	l.console = io.Discard
+	ts := httptest.NewServer(l.handler())
+	t.Cleanup(func() {
+		for _, table := range l.tables {
+			table.s.close()
+		}
+		ts.Close()
+	})
+	return ts
+}
+
+// request sends a JSON request and decodes the JSON answer, returning the status
+func request(t *testing.T, method, url, token string, body any, answer any) int {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, url, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if answer != nil {
+		json.NewDecoder(resp.Body).Decode(answer)
+	}
+	return resp.StatusCode
+}
+
+// dialEvents opens a table's WebSocket feed and returns a reader of its events
+func dialEvents(t *testing.T, ts *httptest.Server, path string) func() apiEvent {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n", path)
+	fmt.Fprintf(conn, "Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected the WebSocket handshake to succeed, but got %v, %v", resp, err)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected the accept key from RFC 6455, but got %v", got)
+	}
+
+	return func() apiEvent {
+		var head [2]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			t.Fatalf("Feed ended: %v", err)
+		}
+		length := int(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			io.ReadFull(r, ext[:])
+			length = int(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			io.ReadFull(r, ext[:])
+			length = int(binary.BigEndian.Uint64(ext[:]))
+		}
+		payload := make([]byte, length)
+		io.ReadFull(r, payload)
+
+		var event apiEvent
+		if head[0]&0x0F == wsText {
+			json.Unmarshal(payload, &event)
+		}
+		return event
+	}
+}
+
+func TestAPIPlaysAHand(t *testing.T) {
+	ts := startTestLobby(t)
+
+	var table tableView
+	if status := request(t, "POST", ts.URL+"/api/tables", "", map[string]any{"name": "Main", "bots": 1}, &table); status != http.StatusCreated {
+		t.Fatalf("Expected the table to be created, but got %v", status)
+	}
+	base := ts.URL + "/api/tables/" + table.ID
+
+	var joined struct {
+		Token string    `json:"token"`
+		View  tableView `json:"view"`
+	}
+	if status := request(t, "POST", base+"/players", "", map[string]string{"name": "alice"}, &joined); status != http.StatusCreated {
+		t.Fatalf("Expected alice to be seated, but got %v", status)
+	}
+	if status := request(t, "POST", base+"/players", "", map[string]string{"name": "Alice"}, nil); status != http.StatusConflict {
+		t.Errorf("Expected a taken name to be refused, but got %v", status)
+	}
+
+	next := dialEvents(t, ts, "/api/tables/"+table.ID+"/events?token="+joined.Token)
+	for {
+		event := next()
+		if you := event.View.You; you != nil && you.Seat >= 0 && len(you.Hand) == 5 && !event.View.Shown {
+			for i, p := range event.View.Players {
+				if i != you.Seat && len(p.Cards) > 0 {
+					t.Fatalf("Expected to see only your own cards, but saw %v's", p.Name)
+				}
+			}
+		}
+
+		switch event.Type {
+		case "action":
+			if status := request(t, "POST", base+"/actions", joined.Token, map[string]any{"action": "raise", "amount": 1}, nil); status != http.StatusBadRequest {
+				t.Errorf("Expected a raise below the minimum to be refused, but got %v", status)
+			}
+			request(t, "POST", base+"/actions", joined.Token, map[string]any{"action": "call"}, nil)
+		case "draw":
+			request(t, "POST", base+"/actions", joined.Token, map[string]any{"action": "draw", "discards": []int{1}}, nil)
+		case "info":
+			if strings.HasPrefix(event.Text, "Chip counts after hand") {
+				return
+			}
+		}
+	}
+}
+
+func TestAPIListsTables(t *testing.T) {
+	ts := startTestLobby(t)
+	request(t, "POST", ts.URL+"/api/tables", "", map[string]any{"bots": 2}, nil)
+
+	var tables []tableSummary
+	request(t, "GET", ts.URL+"/api/tables", "", nil, &tables)
+	if len(tables) != 1 || tables[0].Name != "Table 1" || len(tables[0].Players) != 2 {
+		t.Errorf("Expected Table 1 with two bots, but got %+v", tables)
+	}
+	if status := request(t, "GET", ts.URL+"/api/tables/9", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected a missing table to be a 404, but got %v", status)
+	}
+	if status := request(t, "PUT", ts.URL+"/api/tables/"+tables[0].ID+"/actions", "", nil, nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("Expected a PUT of an action to be a 405, but got %v", status)
+	}
+	if status := request(t, "GET", ts.URL+"/api/tables/"+tables[0].ID+"/chips", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown endpoint to be a 404, but got %v", status)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Betting rounds of a hand
+const (
+	preDraw  = "pre-draw"
+	postDraw = "post-draw"
+)
+
+// bettingStructure limits how much a player may bet or raise
+type bettingStructure int
+
+const (
+	noLimit bettingStructure = iota
+	potLimit
+	fixedLimit
+)
+
+func (b bettingStructure) String() string {
+	switch b {
+	case potLimit:
+		return "Pot-Limit"
+	case fixedLimit:
+		return "Fixed-Limit"
+	}
+	return "No-Limit"
+}
+
+// Convert a command line name such as "pot-limit" to a betting structure
+func parseBettingStructure(name string) (bettingStructure, error) {
+	switch name {
+	case "no-limit", "nl":
+		return noLimit, nil
+	case "pot-limit", "pl":
+		return potLimit, nil
+	case "fixed-limit", "limit", "fl":
+		return fixedLimit, nil
+	}
+	return noLimit, fmt.Errorf("unknown betting structure %q (use no-limit, pot-limit or fixed-limit)", name)
+}
+
+// bettingRules is the betting structure a table plays
+type bettingRules struct {
+	structure bettingStructure
+	smallBet  int // fixed-limit bet size before the draw
+	bigBet    int // fixed-limit bet size after the draw
+	raiseCap  int // fixed-limit bets and raises allowed per round, 0 for no cap
+}
+
+// actionKind is what a player chooses to do when it is their turn
+type actionKind int
+
+const (
+	fold  actionKind = iota
+	call             // also a check when there is nothing to call
+	raise            // also the opening bet of a round
+)
+
+// decision is a player's action; amount is the total bet for a raise
+type decision struct {
+	action actionKind
+	amount int
+}
+
+// strategy chooses actions for a seat, whether it is a person or a bot
+type strategy interface {
+	decide(g *game, seat int) decision
+	discard(g *game, seat int) []int // positions in the hand to replace at the draw
+}
+
+// Errors for raises the betting structure does not allow
+var (
+	errRaiseCapped   = errors.New("the raise cap for this round has been reached")
+	errCannotRaise   = errors.New("you don't have enough chips to raise")
+	errRaiseClosed   = errors.New("you've acted since the last full raise, so you may only call or fold")
+	errRaiseTooSmall = errors.New("raise is below the minimum")
+	errRaiseTooLarge = errors.New("raise is above the maximum")
+)
+
+// Largest bet anyone has made this round
+func (g *game) highestBet() int {
+	highest := 0
+	for _, p := range g.players {
+		if p.bet > highest {
+			highest = p.bet
+		}
+	}
+	return highest
+}
+
+// Chips a seat needs to put in to call, limited by their stack
+func (g *game) toCall(seat int) int {
+	amount := g.highestBet() - g.players[seat].bet
+	if amount > g.players[seat].chips {
+		amount = g.players[seat].chips
+	}
+	return amount
+}
+
+// raiseBounds returns the smallest and largest total bet a seat may raise to,
+// or an error when the seat may only call or fold.
+func (g *game) raiseBounds(seat int) (minTotal, maxTotal int, err error) {
+	p := g.players[seat]
+	highest := g.highestBet()
+	stack := p.bet + p.chips
+	if stack <= highest {
+		return 0, 0, errCannotRaise
+	}
+	if g.closed != nil && g.closed[seat] {
+		return 0, 0, errRaiseClosed
+	}
+
+	switch g.betting.structure {
+	case fixedLimit:
+		if g.betting.raiseCap > 0 && g.raises >= g.betting.raiseCap {
+			return 0, 0, errRaiseCapped
+		}
+		size := g.betting.smallBet
+		if g.round == postDraw {
+			size = g.betting.bigBet
+		}
+		minTotal = highest + size
+		maxTotal = minTotal
+	case potLimit:
+		minTotal = highest + g.lastRaise
+		// The pot after calling, including the call itself
+		maxTotal = highest + g.pot + (highest - p.bet)
+	default:
+		minTotal = highest + g.lastRaise
+		maxTotal = stack
+	}
+
+	// A short stack may always go all in for less
+	if maxTotal > stack {
+		maxTotal = stack
+	}
+	if minTotal > maxTotal {
+		minTotal = maxTotal
+	}
+	return minTotal, maxTotal, nil
+}
+
+// Check a decision against the seat's legal actions, returning an
+// *actionError for one it can't make
+func (g *game) validate(seat int, d decision) error {
+	legal := g.LegalActions(seat)
+	if len(legal) == 0 {
+		return &actionError{seat, d, errNotInHand}
+	}
+	for _, a := range legal {
+		if a.action != d.action {
+			continue
+		}
+		if d.action == raise && d.amount < a.min {
+			return &actionError{seat, d, fmt.Errorf("%w of %d", errRaiseTooSmall, a.min)}
+		}
+		if d.action == raise && d.amount > a.max {
+			return &actionError{seat, d, fmt.Errorf("%w of %d", errRaiseTooLarge, a.max)}
+		}
+		return nil
+	}
+	if d.action == raise {
+		_, _, err := g.raiseBounds(seat)
+		return &actionError{seat, d, err}
+	}
+	return &actionError{seat, d, errUnknownAction}
+}
+
+// Clamp a wanted raise into what the structure allows, or call if raising isn't possible
+func (g *game) clampRaise(seat int, total int) decision {
+	minTotal, maxTotal, err := g.raiseBounds(seat)
+	if err != nil {
+		return decision{action: call}
+	}
+	if total < minTotal {
+		total = minTotal
+	}
+	if total > maxTotal {
+		total = maxTotal
+	}
+	return decision{action: raise, amount: total}
+}
+
+// Move chips from a seat's stack into the pot as part of their bet
+func (g *game) commit(seat int, amount int, kind ledgerKind) {
+	g.players[seat].bet += amount
+	g.players[seat].total += amount
+	g.stake(kind, seat, amount)
+}
+
+// Carry out a decision that has already been validated
+func (g *game) apply(seat int, d decision) {
+	p := &g.players[seat]
+	switch d.action {
+	case fold:
+		p.folded = true
+		fmt.Fprintf(g.out, "%s folds! (Loses %d chips already bet)\n", p.name, p.total)
+	case call:
+		amount := g.toCall(seat)
+		if amount == 0 {
+			fmt.Fprintf(g.out, "%s checks.\n", p.name)
+			return
+		}
+		g.commit(seat, amount, betEntry)
+		fmt.Fprintf(g.out, "%s calls with %d chips. Pot is now %d\n", p.name, amount, g.pot)
+	case raise:
+		highest := g.highestBet()
+		if increase := d.amount - highest; increase > g.lastRaise {
+			g.lastRaise = increase
+		}
+		g.commit(seat, d.amount-p.bet, betEntry)
+		g.raises++
+		if highest == 0 {
+			fmt.Fprintf(g.out, "%s bets %d chips. Pot is now %d\n", p.name, d.amount, g.pot)
+		} else {
+			fmt.Fprintf(g.out, "%s raises to %d chips. Pot is now %d\n", p.name, d.amount, g.pot)
+		}
+	}
+}
+
+// Number of players still contesting the pot
+func (g *game) activeCount() int {
+	count := 0
+	for _, p := range g.players {
+		if !p.folded {
+			count++
+		}
+	}
+	return count
+}
+
+// Next seat after the given one, wrapping around the table
+func (g *game) nextSeat(seat int) int {
+	return (seat + 1) % len(g.players)
+}
+
+// Seat that acts first in the current betting round
+func (g *game) firstToAct() int {
+	if g.round == preDraw {
+		if g.straddleSeat >= 0 {
+			return g.nextSeat(g.straddleSeat)
+		}
+		return g.nextSeat(g.bigBlindSeat)
+	}
+	return g.nextSeat(g.dealer)
+}
+
+// bettingRound asks each player for an action until every bet has been called
+// or folded to. It returns false once only one player is left in the hand.
+func (g *game) bettingRound() bool {
+	fmt.Fprintf(g.out, "\n=== Betting (%s, %s) ===\n", g.round, g.betting.structure)
+
+	g.lastRaise = g.bigBlind
+	if g.round == preDraw {
+		g.raises = 1 // The big blind is the opening bet
+		if g.straddleSeat >= 0 {
+			g.raises = 2
+			g.lastRaise = 2 * g.bigBlind
+		}
+	} else {
+		for i := range g.players {
+			g.players[i].bet = 0
+		}
+		g.raises = 0
+	}
+
+	// Players with chips left who still have to act, none of whom has acted yet
+	g.closed = make([]bool, len(g.players))
+	toAct := make([]bool, len(g.players))
+	for i, p := range g.players {
+		toAct[i] = !p.folded && p.chips > 0
+	}
+
+	seat := g.firstToAct()
+	for pending := true; pending && g.activeCount() > 1; {
+		if toAct[seat] {
+			toAct[seat] = false
+			if g.toCall(seat) > 0 || g.canBeCalled(seat) {
+				g.act(seat, toAct)
+			}
+		}
+
+		pending = false
+		for _, waiting := range toAct {
+			pending = pending || waiting
+		}
+		seat = g.nextSeat(seat)
+	}
+
+	g.returnUncalledBet()
+	return g.activeCount() > 1
+}
+
+// Whether anyone else could still respond to a bet from this seat
+func (g *game) canBeCalled(seat int) bool {
+	for i, p := range g.players {
+		if i != seat && !p.folded && p.chips > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Ask a seat's strategy for a decision and apply it
+func (g *game) act(seat int, toAct []bool) {
+	d := g.decideInTime(seat)
+	if err := g.validate(seat, d); err != nil {
+		if d.action == raise {
+			fmt.Fprintf(g.out, "%s can't raise to %d (%v), calling instead.\n", g.players[seat].name, d.amount, err)
+		} else {
+			fmt.Fprintf(g.out, "%s can't do that (%v), calling instead.\n", g.players[seat].name, err)
+		}
+		d = decision{action: call}
+	}
+	g.coach.record(g, seat, d)
+	g.stats.action(g, seat, d)
+	g.watchAction(seat, d)
+	full := d.action == raise && d.amount-g.highestBet() >= g.fullRaiseSize()
+	g.apply(seat, d)
+	g.audit(fmt.Sprintf("%s's %s", g.players[seat].name, actionNames[d.action]))
+
+	// A raise gives everyone else still in the hand another turn. Only a full
+	// one reopens the betting to those who have acted; after an all in for
+	// less they may just call the difference or fold.
+	if d.action == raise {
+		for i, p := range g.players {
+			toAct[i] = i != seat && !p.folded && p.chips > 0
+			if full {
+				g.closed[i] = false
+			}
+		}
+	}
+	g.closed[seat] = true
+}
+
+// The smallest raise that reopens the betting: the round's bet at fixed
+// limit, or the last full raise otherwise
+func (g *game) fullRaiseSize() int {
+	if g.betting.structure != fixedLimit {
+		return g.lastRaise
+	}
+	if g.round == postDraw {
+		return g.betting.bigBet
+	}
+	return g.betting.smallBet
+}
+
+// Give back the part of the biggest bet nobody matched, such as a raise over a short all in
+func (g *game) returnUncalledBet() {
+	top, second := -1, 0
+	for i, p := range g.players {
+		if top < 0 || p.bet > g.players[top].bet {
+			if top >= 0 {
+				second = g.players[top].bet
+			}
+			top = i
+		} else if p.bet > second {
+			second = p.bet
+		}
+	}
+
+	uncalled := g.players[top].bet - second
+	if uncalled <= 0 {
+		return
+	}
+	g.players[top].bet -= uncalled
+	g.players[top].total -= uncalled
+	g.pay(refundEntry, top, uncalled)
+	fmt.Fprintf(g.out, "Uncalled %d chips returned to %s. Pot is now %d\n", uncalled, g.players[top].name, g.pot)
+}
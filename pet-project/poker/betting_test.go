@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// scripted plays a fixed list of decisions and never draws
+type scripted struct {
+	decisions []decision
+}
+
+func (s *scripted) decide(g *game, seat int) decision {
+	if len(s.decisions) == 0 {
+		return decision{action: call}
+	}
+	d := s.decisions[0]
+	s.decisions = s.decisions[1:]
+	return d
+}
+
+func (s *scripted) discard(g *game, seat int) []int {
+	return nil
+}
+
+func newTestGame(structure bettingStructure) *game {
+	g := newGame()
+	g.betting.structure = structure
+	g.postBlinds()
+	g.lastRaise = g.bigBlind
+	g.raises = 1
+	return g
+}
+
+func TestRaiseBoundsNoLimit(t *testing.T) {
+	g := newTestGame(noLimit)
+
+	minRaise, maxRaise, err := g.raiseBounds(0)
+	if err != nil || minRaise != 100 || maxRaise != 1000 {
+		t.Errorf("Expected no-limit raise between 100 and 1000, but got %v-%v (%v)", minRaise, maxRaise, err)
+	}
+}
+
+func TestRaiseBoundsPotLimit(t *testing.T) {
+	g := newTestGame(potLimit)
+
+	// Pot of 75 plus the 25 call makes 100 on top of the 50 bet
+	minRaise, maxRaise, err := g.raiseBounds(0)
+	if err != nil || minRaise != 100 || maxRaise != 150 {
+		t.Errorf("Expected pot-limit raise between 100 and 150, but got %v-%v (%v)", minRaise, maxRaise, err)
+	}
+}
+
+func TestRaiseBoundsFixedLimitCap(t *testing.T) {
+	g := newTestGame(fixedLimit)
+
+	minRaise, maxRaise, err := g.raiseBounds(0)
+	if err != nil || minRaise != 100 || maxRaise != 100 {
+		t.Errorf("Expected fixed-limit raise to 100, but got %v-%v (%v)", minRaise, maxRaise, err)
+	}
+
+	g.raises = g.betting.raiseCap
+	if _, _, err := g.raiseBounds(0); !errors.Is(err, errRaiseCapped) {
+		t.Errorf("Expected the raise cap to stop raising, but got %v", err)
+	}
+}
+
+func TestValidateRejectsOversizedRaise(t *testing.T) {
+	g := newTestGame(potLimit)
+
+	err := g.validate(0, decision{action: raise, amount: 500})
+	if !errors.Is(err, errRaiseTooLarge) {
+		t.Errorf("Expected a pot-limit overbet to be rejected, but got %v", err)
+	}
+}
+
+func TestBettingRoundConservesChips(t *testing.T) {
+	g := newTestGame(noLimit)
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 200}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 600}}}
+
+	if !g.bettingRound() {
+		t.Fatalf("Expected both players to still be in the hand")
+	}
+
+	if g.players[0].bet != 600 || g.players[1].bet != 600 {
+		t.Errorf("Expected both bets to be 600, but got %v and %v", g.players[0].bet, g.players[1].bet)
+	}
+
+	total := g.pot + g.players[0].chips + g.players[1].chips
+	if total != 2000 {
+		t.Errorf("Expected 2000 chips in play, but got %v", total)
+	}
+}
+
+func TestUncalledBetReturned(t *testing.T) {
+	g := newTestGame(noLimit)
+	g.players[1].chips = 100 // Short stack, 150 in total with the big blind
+	g.openLedger()
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 500}}}
+	g.players[1].strategy = &scripted{}
+
+	g.bettingRound()
+
+	if g.players[0].bet != 150 {
+		t.Errorf("Expected the raise to be cut back to the 150 called, but got %v", g.players[0].bet)
+	}
+	if g.pot != 300 {
+		t.Errorf("Expected a pot of 300, but got %v", g.pot)
+	}
+}
+
+func TestShortAllInDoesNotReopenBetting(t *testing.T) {
+	g := newGame()
+	g.addOpponents(2)
+	g.out = io.Discard
+	g.players[1].chips = 250 // the small blind, who can only go all in for a little more
+	g.postBlinds()
+
+	// You raise 150 more, the small blind goes all in for only 50 more and
+	// the big blind calls. Having acted since, you may only call the 50.
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 200}, {action: raise, amount: 1000}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 250}}}
+	g.players[2].strategy = &scripted{}
+	g.bettingRound()
+
+	for i, p := range g.players {
+		if p.bet != 250 {
+			t.Errorf("Expected everyone to have 250 in, but %s has %d", g.players[i].name, p.bet)
+		}
+	}
+	if _, _, err := g.raiseBounds(0); !errors.Is(err, errRaiseClosed) {
+		t.Errorf("Expected you not to be able to re-raise, but got %v", err)
+	}
+
+	// A full raise would have reopened it
+	g = newGame()
+	g.addOpponents(2)
+	g.out = io.Discard
+	g.postBlinds()
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 200}, {action: raise, amount: 1000}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 350}}}
+	g.players[2].strategy = &scripted{decisions: []decision{{action: fold}}}
+	g.bettingRound()
+	if g.players[0].bet != 1000 {
+		t.Errorf("Expected you to re-raise a full raise to 1000, but you have %d in", g.players[0].bet)
+	}
+}
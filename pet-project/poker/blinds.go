@@ -0,0 +1,160 @@
+package main
+
+import "fmt"
+
+// deadBlindPolicy decides what a player who missed the big blind while
+// sitting out has to do when they come back
+type deadBlindPolicy int
+
+const (
+	waitForBigBlind    deadBlindPolicy = iota // sit out until the big blind reaches them
+	postMissedBlinds                          // post a live big blind and a dead small blind
+	ignoreMissedBlinds                        // play straight away for free
+)
+
+func (d deadBlindPolicy) String() string {
+	switch d {
+	case postMissedBlinds:
+		return "post missed blinds"
+	case ignoreMissedBlinds:
+		return "no dead blinds"
+	}
+	return "wait for the big blind"
+}
+
+// Convert a command line name such as "post" to a dead blind policy
+func parseDeadBlindPolicy(name string) (deadBlindPolicy, error) {
+	switch name {
+	case "wait":
+		return waitForBigBlind, nil
+	case "post":
+		return postMissedBlinds, nil
+	case "none":
+		return ignoreMissedBlinds, nil
+	}
+	return waitForBigBlind, fmt.Errorf("unknown dead blind policy %q (use wait, post or none)", name)
+}
+
+// straddler is a strategy that may choose to straddle when it sits after the big blind
+type straddler interface {
+	straddle(g *game, seat int) bool
+}
+
+// Next seat after the given one that is still in the hand
+func (g *game) nextInHand(seat int) int {
+	for i := 1; i <= len(g.players); i++ {
+		next := (seat + i) % len(g.players)
+		if !g.players[next].folded {
+			return next
+		}
+	}
+	return seat
+}
+
+// seatPlayers deals in everyone with chips who isn't sitting out. Under the
+// wait policy a player who missed the big blind stays out until it is theirs.
+func (g *game) seatPlayers() {
+	for i := range g.players {
+		p := &g.players[i]
+		p.folded = p.chips == 0 || p.sittingOut
+		if p.sittingOut && p.chips > 0 {
+			fmt.Fprintf(g.out, "%s is sitting out.\n", p.name)
+		}
+	}
+
+	if g.deadBlinds != waitForBigBlind {
+		return
+	}
+	for waiting := true; waiting; {
+		waiting = false
+		_, bigBlindPlayer := g.blindSeats()
+		for i := range g.players {
+			p := &g.players[i]
+			if !p.folded && p.missedBlinds && i != bigBlindPlayer {
+				p.folded = true
+				waiting = true
+				fmt.Fprintf(g.out, "%s waits for the big blind.\n", p.name)
+			}
+		}
+	}
+}
+
+// Flag players sitting out whose seat the big blind moved past since last hand
+func (g *game) markMissedBlinds(bigBlindPlayer int) {
+	if g.bigBlindSeat < 0 {
+		return
+	}
+	for seat := g.nextSeat(g.bigBlindSeat); seat != bigBlindPlayer && seat != g.bigBlindSeat; seat = g.nextSeat(seat) {
+		if g.players[seat].sittingOut && g.players[seat].chips > 0 {
+			g.players[seat].missedBlinds = true
+		}
+	}
+}
+
+// Put dead money in the pot; it counts towards the hand but not the current bet
+func (g *game) postDead(seat int, amount int) int {
+	if amount > g.players[seat].chips {
+		amount = g.players[seat].chips
+	}
+	g.players[seat].total += amount
+	g.stake(anteEntry, seat, amount)
+	return amount
+}
+
+func (g *game) postAntes(bigBlindPlayer int) {
+	if g.ante <= 0 {
+		return
+	}
+	if g.bigBlindAnte {
+		amount := g.postDead(bigBlindPlayer, g.ante)
+		fmt.Fprintf(g.out, "%s posts the big blind ante: %d chips\n", g.players[bigBlindPlayer].name, amount)
+		return
+	}
+	for i := range g.players {
+		if !g.players[i].folded {
+			amount := g.postDead(i, g.ante)
+			fmt.Fprintf(g.out, "%s posts ante: %d chips\n", g.players[i].name, amount)
+		}
+	}
+}
+
+// postMissedBlinds settles up with players back from sitting out
+func (g *game) postMissedBlinds(smallBlindPlayer, bigBlindPlayer int) {
+	for i := range g.players {
+		p := &g.players[i]
+		if p.folded || !p.missedBlinds {
+			continue
+		}
+		p.missedBlinds = false
+		if g.deadBlinds != postMissedBlinds || i == smallBlindPlayer || i == bigBlindPlayer {
+			continue
+		}
+
+		live := g.bigBlind
+		if live > p.chips {
+			live = p.chips
+		}
+		g.commit(i, live, blindEntry)
+		dead := g.postDead(i, g.smallBlind)
+		fmt.Fprintf(g.out, "%s posts missed blinds: %d live, %d dead\n", p.name, live, dead)
+	}
+}
+
+// offerStraddle lets the player after the big blind raise blind to twice the big
+// blind. They then act last before the draw.
+func (g *game) offerStraddle(bigBlindPlayer int) {
+	if !g.straddles || g.activeCount() < 3 {
+		return
+	}
+	seat := g.nextInHand(bigBlindPlayer)
+	amount := 2 * g.bigBlind
+	p := &g.players[seat]
+	s, ok := p.strategy.(straddler)
+	if !ok || p.chips+p.bet <= amount || !s.straddle(g, seat) {
+		return
+	}
+
+	g.commit(seat, amount-p.bet, blindEntry)
+	g.straddleSeat = seat
+	fmt.Fprintf(g.out, "%s straddles: %d chips\n", p.name, amount)
+}
@@ -0,0 +1,900 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The solver finds near-equilibrium strategies for small heads-up limit games
+// by counterfactual regret minimisation. Every decision point a player can
+// tell apart (an information set) keeps the regret of not having taken each
+// action; playing in proportion to the positive regrets and averaging over
+// the iterations converges on a Nash equilibrium. CFR+ floors the regrets at
+// zero and weights later iterations more, which converges much faster.
+//
+// It solves Kuhn poker, Leduc hold'em, and an abstraction of this game played
+// heads-up at fixed-limit: hands are put in buckets before and after the
+// draw, everyone draws the standard way, and a showdown between two buckets
+// is won as often as the simulation says.
+
+// chanceOutcome is one way the cards can come
+type chanceOutcome struct {
+	cards  [2]int // the card or bucket each player is dealt, or -1 for none
+	public int    // a card both players see, or -1
+	p      float64
+}
+
+// solverGame is a small two-player limit game
+type solverGame struct {
+	name     string
+	unit     string     // what the payoffs are counted in
+	blinds   [2]float64 // what each player has in before the first round
+	bets     []float64  // bet size in each round
+	opened   []int      // bets already made as each round starts; a big blind counts as one
+	raiseCap int        // bets and raises allowed in a round
+	first    []int      // player first to act in each round
+	names    [][]string // names of the first card or bucket each player is dealt, then the second
+	deal     func() []chanceOutcome
+	next     func(s solverState) []chanceOutcome // the cards between rounds
+	win      func(s solverState) float64         // player 0's share of the pot at showdown
+}
+
+// solverState is a point in a hand. history holds the betting in each round
+// so far: f to fold, c to check or call, r to bet or raise. The games have no
+// more than two rounds, so it fits in arrays and is copied as a value, which
+// saves the solver a great deal of garbage.
+type solverState struct {
+	seen    [2][2]int // cards or buckets each player has been dealt
+	dealt   int       // how many of them
+	public  int       // a card on the board, or -1
+	history [2]string
+	rounds  int // rounds started
+	put     [2]float64
+	folded  int // the player who folded, or -1
+}
+
+func (g *solverGame) root() solverState {
+	return solverState{public: -1, put: g.blinds, folded: -1}
+}
+
+func (s solverState) round() int {
+	return s.rounds - 1
+}
+
+// Whether the betting in the current round is over
+func (s solverState) roundOver() bool {
+	if s.rounds == 0 {
+		return true
+	}
+	h := s.history[s.round()]
+	return len(h) >= 2 && h[len(h)-1] == 'c'
+}
+
+func (g *solverGame) terminal(s solverState) bool {
+	return s.folded >= 0 || (s.roundOver() && s.rounds == len(g.bets))
+}
+
+// Whether the cards come next, rather than a player's decision
+func (g *solverGame) chance(s solverState) bool {
+	return s.roundOver()
+}
+
+func (g *solverGame) outcomes(s solverState) []chanceOutcome {
+	if s.rounds == 0 {
+		return g.deal()
+	}
+	return g.next(s)
+}
+
+func (g *solverGame) toAct(s solverState) int {
+	r := s.round()
+	return (g.first[r] + len(s.history[r])) % 2
+}
+
+// actions lists what the player to act may do, as f, c and r
+func (g *solverGame) actions(s solverState) string {
+	r := s.round()
+	p := g.toAct(s)
+	acts := "c"
+	if s.put[p] < s.put[1-p] {
+		acts = "fc"
+	}
+	if g.opened[r]+strings.Count(s.history[r], "r") < g.raiseCap {
+		acts += "r"
+	}
+	return acts
+}
+
+func (g *solverGame) play(s solverState, a byte) solverState {
+	next := s
+	p, r := g.toAct(s), s.round()
+	switch a {
+	case 'f':
+		next.folded = p
+	case 'c':
+		next.put[p] = next.put[1-p]
+	case 'r':
+		next.put[p] = next.put[1-p] + g.bets[r]
+	}
+	next.history[r] += string(a)
+	return next
+}
+
+// dealt starts the next round with the cards that come
+func (g *solverGame) dealt(s solverState, o chanceOutcome) solverState {
+	next := s
+	if o.cards[0] >= 0 {
+		next.seen[0][s.dealt] = o.cards[0]
+		next.seen[1][s.dealt] = o.cards[1]
+		next.dealt++
+	}
+	if o.public >= 0 {
+		next.public = o.public
+	}
+	next.rounds++
+	return next
+}
+
+// payoff is what player 0 wins, or loses, at the end of a hand
+func (g *solverGame) payoff(s solverState) float64 {
+	switch s.folded {
+	case 0:
+		return -s.put[0]
+	case 1:
+		return s.put[1]
+	}
+	w := g.win(s)
+	return w*s.put[1] - (1-w)*s.put[0]
+}
+
+// key names an information set: the cards the player has seen, the public
+// cards and the betting, such as "K|Q:cr/r"
+func (g *solverGame) key(s solverState, p int) string {
+	var b strings.Builder
+	for i, c := range s.seen[p][:s.dealt] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(g.names[i][c])
+	}
+	if s.public >= 0 {
+		b.WriteByte('|')
+		b.WriteString(g.names[0][s.public])
+	}
+	b.WriteByte(':')
+	b.WriteString(strings.Join(s.history[:s.rounds], "/"))
+	return b.String()
+}
+
+// fullKey names a state with both players' cards, for remembering its value
+type fullKey struct {
+	seen    [2][2]int
+	public  int
+	history [2]string
+}
+
+// A policy gives the chances of each action at an information set
+type policy func(key string, actions string) []float64
+
+func uniform(actions string) []float64 {
+	probs := make([]float64, len(actions))
+	for i := range probs {
+		probs[i] = 1 / float64(len(actions))
+	}
+	return probs
+}
+
+// value is what player 0 expects to win when both sides play one policy
+func (g *solverGame) value(pol policy) float64 {
+	var walk func(s solverState) float64
+	walk = func(s solverState) float64 {
+		switch {
+		case g.terminal(s):
+			return g.payoff(s)
+		case g.chance(s):
+			v := 0.0
+			for _, o := range g.outcomes(s) {
+				v += o.p * walk(g.dealt(s, o))
+			}
+			return v
+		}
+		acts := g.actions(s)
+		v := 0.0
+		for i, prob := range pol(g.key(s, g.toAct(s)), acts) {
+			if prob > 0 {
+				v += prob * walk(g.play(s, acts[i]))
+			}
+		}
+		return v
+	}
+	return walk(g.root())
+}
+
+// bestResponse is the most player p can expect to win against a policy for
+// the other player. Each of p's information sets picks the action that does
+// best over every state it could be in, weighted by how likely the cards and
+// the other player's play make that state.
+func (g *solverGame) bestResponse(pol policy, p int) float64 {
+	type weighted struct {
+		s solverState
+		w float64
+	}
+	infoSets := make(map[string][]weighted)
+	var collect func(s solverState, w float64)
+	collect = func(s solverState, w float64) {
+		switch {
+		case g.terminal(s):
+			return
+		case g.chance(s):
+			for _, o := range g.outcomes(s) {
+				collect(g.dealt(s, o), w*o.p)
+			}
+			return
+		}
+		acts := g.actions(s)
+		q := g.toAct(s)
+		if q == p {
+			key := g.key(s, p)
+			infoSets[key] = append(infoSets[key], weighted{s, w})
+			for i := range acts {
+				collect(g.play(s, acts[i]), w)
+			}
+			return
+		}
+		for i, prob := range pol(g.key(s, q), acts) {
+			if prob > 0 {
+				collect(g.play(s, acts[i]), w*prob)
+			}
+		}
+	}
+	collect(g.root(), 1)
+
+	sign := 1.0
+	if p == 1 {
+		sign = -1
+	}
+	best := make(map[string]byte)
+	values := make(map[fullKey]float64)
+	var value func(s solverState) float64
+	choose := func(key string) byte {
+		if a, ok := best[key]; ok {
+			return a
+		}
+		acts := g.actions(infoSets[key][0].s)
+		choice, most := acts[0], 0.0
+		for i := range acts {
+			total := 0.0
+			for _, h := range infoSets[key] {
+				total += h.w * value(g.play(h.s, acts[i]))
+			}
+			if i == 0 || total > most {
+				choice, most = acts[i], total
+			}
+		}
+		best[key] = choice
+		return choice
+	}
+	value = func(s solverState) float64 {
+		full := fullKey{s.seen, s.public, s.history}
+		if v, ok := values[full]; ok {
+			return v
+		}
+		v := 0.0
+		switch {
+		case g.terminal(s):
+			v = sign * g.payoff(s)
+		case g.chance(s):
+			for _, o := range g.outcomes(s) {
+				v += o.p * value(g.dealt(s, o))
+			}
+		case g.toAct(s) == p:
+			v = value(g.play(s, choose(g.key(s, p))))
+		default:
+			acts := g.actions(s)
+			for i, prob := range pol(g.key(s, g.toAct(s)), acts) {
+				if prob > 0 {
+					v += prob * value(g.play(s, acts[i]))
+				}
+			}
+		}
+		values[full] = v
+		return v
+	}
+	return value(g.root())
+}
+
+// exploitability is how much a best response wins against a policy, averaged
+// over the two seats. It is zero at a Nash equilibrium.
+func (g *solverGame) exploitability(pol policy) float64 {
+	return (g.bestResponse(pol, 0) + g.bestResponse(pol, 1)) / 2
+}
+
+// cfrNode is the solver's record of one information set
+type cfrNode struct {
+	actions string
+	regret  []float64
+	change  []float64 // regret gathered this iteration, added once it's over
+	total   []float64 // the strategy played, weighted by reach, to average
+}
+
+// current plays in proportion to the positive regrets
+func (n *cfrNode) current() []float64 {
+	probs := make([]float64, len(n.actions))
+	sum := 0.0
+	for i, r := range n.regret {
+		probs[i] = max(r, 0)
+		sum += probs[i]
+	}
+	if sum == 0 {
+		return uniform(n.actions)
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return probs
+}
+
+func (n *cfrNode) average() []float64 {
+	sum := 0.0
+	for _, t := range n.total {
+		sum += t
+	}
+	if sum == 0 {
+		return uniform(n.actions)
+	}
+	probs := make([]float64, len(n.total))
+	for i, t := range n.total {
+		probs[i] = t / sum
+	}
+	return probs
+}
+
+type cfrSolver struct {
+	game       *solverGame
+	plus       bool
+	nodes      map[string]*cfrNode
+	iterations int
+}
+
+func newCFRSolver(g *solverGame, plus bool) *cfrSolver {
+	return &cfrSolver{game: g, plus: plus, nodes: make(map[string]*cfrNode)}
+}
+
+// run does more iterations, each updating one player and then the other.
+// An information set is reached from many deals, so its regrets only change
+// once they have all been walked, keeping its strategy the same throughout.
+func (sv *cfrSolver) run(iterations int) {
+	for i := 0; i < iterations; i++ {
+		sv.iterations++
+		for p := 0; p < 2; p++ {
+			sv.walk(sv.game.root(), p, [2]float64{1, 1}, 1)
+			for _, n := range sv.nodes {
+				for a, c := range n.change {
+					n.regret[a] += c
+					if sv.plus {
+						n.regret[a] = max(n.regret[a], 0)
+					}
+					n.change[a] = 0
+				}
+			}
+		}
+	}
+}
+
+// walk returns what player 0 expects to win from a state under the current
+// strategies, updating player p's regrets on the way back up
+func (sv *cfrSolver) walk(s solverState, p int, reach [2]float64, chance float64) float64 {
+	g := sv.game
+	switch {
+	case g.terminal(s):
+		return g.payoff(s)
+	case g.chance(s):
+		v := 0.0
+		for _, o := range g.outcomes(s) {
+			v += o.p * sv.walk(g.dealt(s, o), p, reach, chance*o.p)
+		}
+		return v
+	}
+
+	q := g.toAct(s)
+	key := g.key(s, q)
+	n, ok := sv.nodes[key]
+	if !ok {
+		acts := g.actions(s)
+		n = &cfrNode{actions: acts, regret: make([]float64, len(acts)), change: make([]float64, len(acts)), total: make([]float64, len(acts))}
+		sv.nodes[key] = n
+	}
+	// Nothing below here can change if neither the updating player nor what
+	// they play against can reach it
+	if reach[1-p]*chance == 0 && reach[p] == 0 {
+		return 0
+	}
+
+	probs := n.current()
+	values := make([]float64, len(n.actions))
+	v := 0.0
+	for i := range n.actions {
+		next := reach
+		next[q] *= probs[i]
+		values[i] = sv.walk(g.play(s, n.actions[i]), p, next, chance)
+		v += probs[i] * values[i]
+	}
+	if q != p {
+		return v
+	}
+
+	sign := 1.0
+	if q == 1 {
+		sign = -1
+	}
+	weight := 1.0
+	if sv.plus {
+		weight = float64(sv.iterations) // later iterations count for more
+	}
+	for i := range n.actions {
+		n.change[i] += sign * reach[1-q] * chance * (values[i] - v)
+		n.total[i] += weight * reach[q] * probs[i]
+	}
+	return v
+}
+
+// average is the policy the solver converges on
+func (sv *cfrSolver) average() policy {
+	return func(key string, actions string) []float64 {
+		if n, ok := sv.nodes[key]; ok {
+			return n.average()
+		}
+		return uniform(actions)
+	}
+}
+
+// Names of the solver's actions, as strategies are exported
+var solverActions = map[byte]string{'f': actionNames[fold], 'c': actionNames[call], 'r': actionNames[raise]}
+
+// solvedStrategy is a solved policy as written to a file
+type solvedStrategy struct {
+	Game           string                        `json:"game"`
+	Iterations     int                           `json:"iterations"`
+	Plus           bool                          `json:"plus"`
+	Exploitability float64                       `json:"exploitability"`
+	RaiseCap       int                           `json:"raiseCap"`
+	Strategy       map[string]map[string]float64 `json:"strategy"`
+}
+
+func (sv *cfrSolver) export(exploitability float64) *solvedStrategy {
+	out := &solvedStrategy{
+		Game:           sv.game.name,
+		Iterations:     sv.iterations,
+		Plus:           sv.plus,
+		Exploitability: exploitability,
+		RaiseCap:       sv.game.raiseCap,
+		Strategy:       make(map[string]map[string]float64),
+	}
+	for key, n := range sv.nodes {
+		probs := make(map[string]float64)
+		for i, p := range n.average() {
+			probs[solverActions[n.actions[i]]] = p
+		}
+		out.Strategy[key] = probs
+	}
+	return out
+}
+
+// policy plays a strategy read back from a file
+func (ss *solvedStrategy) policy() policy {
+	return func(key string, actions string) []float64 {
+		probs, ok := ss.Strategy[key]
+		if !ok {
+			return uniform(actions)
+		}
+		out := make([]float64, len(actions))
+		for i := range actions {
+			out[i] = probs[solverActions[actions[i]]]
+		}
+		return out
+	}
+}
+
+func loadStrategy(path string) (*solvedStrategy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ss solvedStrategy
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(ss.Strategy) == 0 {
+		return nil, fmt.Errorf("%s: no strategy in the file", path)
+	}
+	return &ss, nil
+}
+
+// kuhnPoker is played with a Jack, Queen and King: one card each, an ante of
+// one, and a single round where one bet of one may be made
+func kuhnPoker() *solverGame {
+	return &solverGame{
+		name:     "kuhn",
+		unit:     "antes",
+		blinds:   [2]float64{1, 1},
+		bets:     []float64{1},
+		opened:   []int{0},
+		raiseCap: 1,
+		first:    []int{0},
+		names:    [][]string{{"J", "Q", "K"}},
+		deal: func() []chanceOutcome {
+			var deals []chanceOutcome
+			for a := 0; a < 3; a++ {
+				for b := 0; b < 3; b++ {
+					if a != b {
+						deals = append(deals, chanceOutcome{cards: [2]int{a, b}, public: -1, p: 1.0 / 6})
+					}
+				}
+			}
+			return deals
+		},
+		win: func(s solverState) float64 {
+			if s.seen[0][0] > s.seen[1][0] {
+				return 1
+			}
+			return 0
+		},
+	}
+}
+
+// leducHoldem is played with two Jacks, Queens and Kings: one card each and
+// an ante of one, a round of betting in twos, a card on the board, and a
+// round of betting in fours. Two bets are allowed in a round, and pairing
+// the board beats a higher card.
+func leducHoldem() *solverGame {
+	g := &solverGame{
+		name:     "leduc",
+		unit:     "antes",
+		blinds:   [2]float64{1, 1},
+		bets:     []float64{2, 4},
+		opened:   []int{0, 0},
+		raiseCap: 2,
+		first:    []int{0, 0},
+		names:    [][]string{{"J", "Q", "K"}},
+	}
+	g.deal = func() []chanceOutcome {
+		var deals []chanceOutcome
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				p := 4.0 / 30 // two cards of each rank
+				if a == b {
+					p = 2.0 / 30
+				}
+				deals = append(deals, chanceOutcome{cards: [2]int{a, b}, public: -1, p: p})
+			}
+		}
+		return deals
+	}
+	g.next = func(s solverState) []chanceOutcome {
+		var boards []chanceOutcome
+		for c := 0; c < 3; c++ {
+			left := 2
+			for p := 0; p < 2; p++ {
+				if s.seen[p][0] == c {
+					left--
+				}
+			}
+			if left > 0 {
+				boards = append(boards, chanceOutcome{cards: [2]int{-1, -1}, public: c, p: float64(left) / 4})
+			}
+		}
+		return boards
+	}
+	g.win = func(s solverState) float64 {
+		a, b, board := s.seen[0][0], s.seen[1][0], s.public
+		switch {
+		case a == b:
+			return 0.5
+		case a == board:
+			return 1
+		case b == board:
+			return 0
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	return g
+}
+
+// Buckets for the draw abstraction, before the draw and after it
+var (
+	preDrawBuckets  = []string{"Junk", "Draw", "Low Pair", "High Pair", "Two Pair", "Trips or better"}
+	postDrawBuckets = []string{"High Card", "Low Pair", "High Pair", "Two Pair", "Trips", "Straight or better"}
+)
+
+// preDrawBucket groups the starting hand buckets: a pair of Tens or better is high
+func preDrawBucket(hand deck) int {
+	b := handBucket(hand)
+	switch {
+	case b < 2:
+		return 0
+	case b < bucketPairOfTwos:
+		return 1
+	case b < bucketPairOfTwos+8:
+		return 2
+	case b < bucketTwoPair:
+		return 3
+	case b == bucketTwoPair:
+		return 4
+	}
+	return 5
+}
+
+func postDrawBucket(hand deck) int {
+	rank := evaluateHand(hand.toCards())
+	switch {
+	case rank.rank >= 5:
+		return 5
+	case rank.rank >= 3:
+		return rank.rank
+	case rank.rank == 2 && rank.values[0] >= 10:
+		return 2
+	}
+	return rank.rank - 1
+}
+
+// drawAbstraction is heads-up fixed-limit five card draw in big blinds, with
+// blinds of a half and one, bets of one before the draw and two after, and a
+// cap of four bets a round. Dealing hands and drawing them the standard way
+// measures how often each bucket comes, what it draws to, and how often each
+// bucket after the draw beats each other one.
+func drawAbstraction(deals int, rng *rand.Rand) *solverGame {
+	pre, post := len(preDrawBuckets), len(postDrawBuckets)
+	freq := make([]float64, pre)
+	draws := make([][]float64, pre)
+	for i := range draws {
+		draws[i] = make([]float64, post)
+	}
+	won := make([][]float64, post)
+	played := make([][]float64, post)
+	for i := range won {
+		won[i] = make([]float64, post)
+		played[i] = make([]float64, post)
+	}
+
+	for i := 0; i < deals; i++ {
+		stub := remainingDeck(rng)
+		var hands [2]deck
+		hands[0], stub = deal(stub, 5)
+		hands[1], stub = deal(stub, 5)
+		var after [2]int
+		for p, hand := range hands {
+			b := preDrawBucket(hand)
+			freq[b]++
+			hands[p], stub = drawOut(hand, stub)
+			after[p] = postDrawBucket(hands[p])
+			draws[b][after[p]]++
+		}
+		share := 0.5
+		switch compareHands(evaluateHand(hands[0].toCards()), evaluateHand(hands[1].toCards())) {
+		case 1:
+			share = 1
+		case -1:
+			share = 0
+		}
+		won[after[0]][after[1]] += share
+		played[after[0]][after[1]]++
+		won[after[1]][after[0]] += 1 - share
+		played[after[1]][after[0]]++
+	}
+
+	for b := range draws {
+		total := freq[b]
+		for c := range draws[b] {
+			if total > 0 {
+				draws[b][c] /= total
+			}
+		}
+		freq[b] /= float64(2 * deals)
+	}
+	for a := range won {
+		for b := range won[a] {
+			if played[a][b] == 0 {
+				won[a][b] = 0.5
+			} else {
+				won[a][b] /= played[a][b]
+			}
+		}
+	}
+
+	return &solverGame{
+		name:     "draw",
+		unit:     "big blinds",
+		blinds:   [2]float64{0.5, 1},
+		bets:     []float64{1, 2},
+		opened:   []int{1, 0},
+		raiseCap: 4,
+		first:    []int{0, 1}, // the small blind first before the draw, the big blind after
+		names:    [][]string{preDrawBuckets, postDrawBuckets},
+		deal: func() []chanceOutcome {
+			var out []chanceOutcome
+			for a := range freq {
+				for b := range freq {
+					if p := freq[a] * freq[b]; p > 0 {
+						out = append(out, chanceOutcome{cards: [2]int{a, b}, public: -1, p: p})
+					}
+				}
+			}
+			return out
+		},
+		next: func(s solverState) []chanceOutcome {
+			var out []chanceOutcome
+			from0, from1 := draws[s.seen[0][0]], draws[s.seen[1][0]]
+			for a := range from0 {
+				for b := range from1 {
+					if p := from0[a] * from1[b]; p > 0 {
+						out = append(out, chanceOutcome{cards: [2]int{a, b}, public: -1, p: p})
+					}
+				}
+			}
+			return out
+		},
+		win: func(s solverState) float64 {
+			return won[s.seen[0][1]][s.seen[1][1]]
+		},
+	}
+}
+
+// solvedBot plays a solved draw abstraction in a heads-up fixed-limit game,
+// and the computer's usual game anywhere the abstraction doesn't fit
+type solvedBot struct {
+	strategy *solvedStrategy
+	pre      int      // the bucket its hand was in before the draw, or -1
+	history  []string // the betting in each round of the hand
+}
+
+func newSolvedBot(ss *solvedStrategy) *solvedBot {
+	return &solvedBot{strategy: ss, pre: -1}
+}
+
+// key is the bot's information set in the abstraction, if the game fits it
+func (b *solvedBot) key(g *game, seat int) (string, bool) {
+	if len(g.players) != 2 || g.betting.structure != fixedLimit || g.betting.raiseCap != b.strategy.RaiseCap ||
+		g.betting.smallBet != g.bigBlind || g.betting.bigBet != 2*g.bigBlind || 2*g.smallBlind != g.bigBlind ||
+		g.wilds != (wildRules{}) || g.ante > 0 || g.straddleSeat >= 0 {
+		return "", false
+	}
+	hand := g.players[seat].hand
+	if g.round == preDraw {
+		b.pre = preDrawBucket(hand)
+		return preDrawBuckets[b.pre] + ":" + b.betting(0), true
+	}
+	if b.pre < 0 {
+		return "", false
+	}
+	return preDrawBuckets[b.pre] + "," + postDrawBuckets[postDrawBucket(hand)] + ":" + b.betting(0) + "/" + b.betting(1), true
+}
+
+func (b *solvedBot) betting(round int) string {
+	if round < len(b.history) {
+		return b.history[round]
+	}
+	return ""
+}
+
+func (b *solvedBot) decide(g *game, seat int) decision {
+	key, ok := b.key(g, seat)
+	probs, known := b.strategy.Strategy[key]
+	if !ok || !known {
+		return g.computerAction(seat)
+	}
+	r := g.random()
+	switch {
+	case r < probs[actionNames[fold]]:
+		if g.toCall(seat) == 0 {
+			return decision{action: call} // Never fold when checking is free
+		}
+		return decision{action: fold}
+	case r < probs[actionNames[fold]]+probs[actionNames[call]]:
+		return decision{action: call}
+	}
+	return g.clampRaise(seat, g.highestBet())
+}
+
+func (b *solvedBot) discard(g *game, seat int) []int {
+	return g.computerDiscards(seat)
+}
+
+// watchAction keeps the betting the way the abstraction writes it
+func (b *solvedBot) watchAction(g *game, seat int, d decision) {
+	round := 0
+	if g.round == postDraw {
+		round = 1
+	}
+	for len(b.history) <= round {
+		b.history = append(b.history, "")
+	}
+	b.history[round] += actionNames[d.action][:1]
+}
+
+func (b *solvedBot) watchHandEnd(g *game, showdown bool) {
+	b.pre, b.history = -1, nil
+}
+
+// Games the solver knows, by name
+var solverGames = map[string]func(rng *rand.Rand) *solverGame{
+	"kuhn":  func(*rand.Rand) *solverGame { return kuhnPoker() },
+	"leduc": func(*rand.Rand) *solverGame { return leducHoldem() },
+	"draw":  func(rng *rand.Rand) *solverGame { return drawAbstraction(20000, rng) },
+}
+
+// runSolve is the solve subcommand: it runs CFR or CFR+ on a small game,
+// reporting the exploitability as it goes, and can write the strategy out
+// for -strategy to play
+func runSolve(args []string) error {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	name := flags.String("game", "kuhn", "game to solve: kuhn, leduc or draw (heads-up fixed-limit five card draw, abstracted)")
+	iterations := flags.Int("iterations", 1000, "iterations of CFR to run")
+	vanilla := flags.Bool("vanilla", false, "run plain CFR instead of CFR+")
+	reports := flags.Int("reports", 10, "times to report the exploitability along the way")
+	out := flags.String("out", "", "file to write the solved strategy to")
+	seed := flags.Int64("seed", 0, "random seed for measuring the draw abstraction (0 picks one)")
+	flags.Parse(args)
+
+	makeGame, ok := solverGames[*name]
+	if !ok {
+		return fmt.Errorf("unknown game %q: use kuhn, leduc or draw", *name)
+	}
+	if *iterations < 1 || *reports < 1 {
+		return errors.New("-iterations and -reports must be at least 1")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g := makeGame(rand.New(rand.NewSource(*seed)))
+	sv := newCFRSolver(g, !*vanilla)
+	method := "CFR+"
+	if *vanilla {
+		method = "CFR"
+	}
+
+	fmt.Printf("Solving %s with %s for %d iterations...\n", g.name, method, *iterations)
+	step := max(1, *iterations / *reports)
+	for sv.iterations < *iterations {
+		sv.run(min(step, *iterations-sv.iterations))
+		fmt.Printf("Iteration %d: exploitability %.4f %s a hand\n", sv.iterations, g.exploitability(sv.average()), g.unit)
+	}
+
+	exploitability := g.exploitability(sv.average())
+	fmt.Printf("\nGame value for the first player: %+.4f %s a hand\n", g.value(sv.average()), g.unit)
+	fmt.Printf("Exploitability: %.4f %s a hand\n", exploitability, g.unit)
+	solved := sv.export(exploitability)
+	if len(solved.Strategy) <= 30 {
+		keys := make([]string, 0, len(solved.Strategy))
+		for key := range solved.Strategy {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Printf("\n%-12s %6s %6s %6s\n", "Situation", "Fold", "Call", "Raise")
+		for _, key := range keys {
+			probs := solved.Strategy[key]
+			fmt.Printf("%-12s %5.0f%% %5.0f%% %5.0f%%\n", key, 100*probs["fold"], 100*probs["call"], 100*probs["raise"])
+		}
+	}
+
+	if *out == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(solved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Strategy written to %s\n", *out)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBestResponseToKuhnEquilibrium(t *testing.T) {
+	g := kuhnPoker()
+	// The first player never bets, and calls with a Queen a third of the time.
+	// The second player bets a King, and a Jack a third of the time.
+	equilibrium := map[string][]float64{
+		"J:": {1, 0}, "Q:": {1, 0}, "K:": {1, 0},
+		"J:cr": {1, 0}, "Q:cr": {2.0 / 3, 1.0 / 3}, "K:cr": {0, 1},
+		"J:c": {2.0 / 3, 1.0 / 3}, "Q:c": {1, 0}, "K:c": {0, 1},
+		"J:r": {1, 0}, "Q:r": {2.0 / 3, 1.0 / 3}, "K:r": {0, 1},
+	}
+	pol := func(key string, actions string) []float64 { return equilibrium[key] }
+	if v := g.value(pol); math.Abs(v+1.0/18) > 1e-9 {
+		t.Errorf("Expected the first player to lose 1/18 of an ante a hand, but got %v", v)
+	}
+	if e := g.exploitability(pol); math.Abs(e) > 1e-9 {
+		t.Errorf("Expected an equilibrium not to be exploitable, but got %v", e)
+	}
+	random := func(key string, actions string) []float64 { return uniform(actions) }
+	if e := g.exploitability(random); e < 0.3 {
+		t.Errorf("Expected playing at random to be exploitable, but got %v", e)
+	}
+}
+
+func TestCFRConverges(t *testing.T) {
+	kuhn := kuhnPoker()
+	plus, vanilla := newCFRSolver(kuhn, true), newCFRSolver(kuhn, false)
+	plus.run(1000)
+	vanilla.run(1000)
+	if e := kuhn.exploitability(plus.average()); e > 0.001 {
+		t.Errorf("Expected CFR+ to solve Kuhn poker, but its exploitability is %v", e)
+	}
+	if e := kuhn.exploitability(vanilla.average()); e > 0.02 {
+		t.Errorf("Expected CFR to get close on Kuhn poker, but its exploitability is %v", e)
+	}
+	if v := kuhn.value(plus.average()); math.Abs(v+1.0/18) > 0.002 {
+		t.Errorf("Expected the game to be worth -1/18 to the first player, but got %v", v)
+	}
+
+	leduc := leducHoldem()
+	sv := newCFRSolver(leduc, true)
+	sv.run(100)
+	if e := leduc.exploitability(sv.average()); e > 0.05 {
+		t.Errorf("Expected CFR+ to get close on Leduc hold'em, but its exploitability is %v", e)
+	}
+}
+
+func TestSolvedDrawStrategyPlays(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	g := drawAbstraction(2000, rng)
+	sv := newCFRSolver(g, true)
+	sv.run(3)
+	exploitability := g.exploitability(sv.average())
+
+	// The strategy reads back as it was written
+	path := filepath.Join(t.TempDir(), "draw.json")
+	data, _ := json.Marshal(sv.export(exploitability))
+	os.WriteFile(path, data, 0o644)
+	ss, err := loadStrategy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := g.exploitability(ss.policy()); math.Abs(e-exploitability) > 1e-9 || ss.Game != "draw" || ss.Iterations != 3 {
+		t.Errorf("Expected the strategy to read back, but got %s after %d iterations, exploitable by %v rather than %v",
+			ss.Game, ss.Iterations, e, exploitability)
+	}
+
+	// Heads-up at fixed-limit the bot finds its spot in the abstraction
+	table := newGame()
+	table.out = io.Discard
+	table.betting = bettingRules{structure: fixedLimit, smallBet: 50, bigBet: 100, raiseCap: 4}
+	bot := newSolvedBot(ss)
+	table.players[1].strategy = bot
+	table.postBlinds()
+	table.dealHands()
+	table.players[1].hand = deck{"Ace of Spades", "Ace of Hearts", "Seven of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	table.watchAction(0, decision{action: raise, amount: 100})
+	table.apply(0, decision{action: raise, amount: 100})
+	if key, ok := bot.key(table, 1); !ok || key != "High Pair:r" {
+		t.Errorf("Expected the bot to be at High Pair:r, but got %q", key)
+	}
+	ss.Strategy["High Pair:r"] = map[string]float64{"raise": 1}
+	if d := bot.decide(table, 1); d.action != raise || d.amount != 150 {
+		t.Errorf("Expected the bot to re-raise to 150, but got %+v", d)
+	}
+	table.round = postDraw
+	table.players[1].hand = deck{"Ace of Spades", "Ace of Hearts", "Ace of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	if key, ok := bot.key(table, 1); !ok || key != "High Pair,Trips:r/" {
+		t.Errorf("Expected the bot to be at High Pair,Trips:r/, but got %q", key)
+	}
+
+	// Anywhere else it plays the computer's usual game
+	table.betting.structure = noLimit
+	if key, ok := bot.key(table, 1); ok {
+		t.Errorf("Expected no-limit not to fit the abstraction, but got %q", key)
+	}
+}
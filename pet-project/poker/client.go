@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// runConnect is the connect subcommand: a terminal client for poker serve. It
+// shows the server's messages as plain text and turns what you type into
+// protocol commands, so "raise 200" is sent as "RAISE 200". After a dropped
+// connection, -resume with the token you were seated with takes your seat back.
+func runConnect(args []string) error {
+	flags := flag.NewFlagSet("connect", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:7777", "address of the poker server")
+	name := flags.String("name", "", "name to join the table with")
+	commentate := flags.Bool("commentate", false, "watch with every player's cards, if the server allows it, instead of joining")
+	resume := flags.String("resume", "", "session token to take back a seat with after a dropped connection")
+	flags.Parse(args)
+
+	conn, err := net.Dial("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch {
+	case *resume != "":
+		fmt.Fprintf(conn, "RESUME %s\n", *resume)
+	case *commentate:
+		fmt.Fprintln(conn, "COMMENTATE")
+	case *name != "":
+		fmt.Fprintf(conn, "JOIN %s\n", *name)
+	}
+
+	// Send whatever is typed, with the command word in capitals
+	go func() {
+		input := bufio.NewScanner(os.Stdin)
+		for input.Scan() {
+			fields := strings.Fields(input.Text())
+			if len(fields) == 0 {
+				continue
+			}
+			fields[0] = strings.ToUpper(fields[0])
+			fmt.Fprintln(conn, strings.Join(fields, " "))
+		}
+		fmt.Fprintln(conn, "QUIT")
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if showServerLine(os.Stdout, scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// showServerLine prints a protocol message for a person to read, returning
+// true once the server says goodbye
+func showServerLine(w io.Writer, line string) bool {
+	command, rest, _ := strings.Cut(line, " ")
+	switch command {
+	case "WELCOME", "INFO":
+		fmt.Fprintln(w, rest)
+	case "SEATED":
+		name, token, _ := strings.Cut(rest, " ")
+		fmt.Fprintf(w, "You're seated as %s. If your connection drops, come back with -resume %s\n", name, token)
+	case "TURN":
+		fmt.Fprintf(w, "Waiting for %s to act.\n", rest)
+	case "HAND":
+		rank, cards, _ := strings.Cut(rest, ": ")
+		fmt.Fprintln(w, "\n=== Your Hand ===")
+		fmt.Fprintln(w, cards)
+		fmt.Fprintf(w, "Your hand: %s\n", rank)
+	case "ACTION":
+		var toCall, minRaise, maxRaise, seconds int
+		fmt.Sscan(rest, &toCall, &minRaise, &maxRaise, &seconds)
+		callLabel := "check"
+		if toCall > 0 {
+			callLabel = fmt.Sprintf("call (%d)", toCall)
+		}
+		if maxRaise > 0 {
+			fmt.Fprintf(w, "\nYour turn%s: fold, %s, or raise <%d-%d>: ", secondsLabel(seconds), callLabel, minRaise, maxRaise)
+		} else {
+			fmt.Fprintf(w, "\nYour turn%s: fold or %s: ", secondsLabel(seconds), callLabel)
+		}
+	case "DRAW":
+		var most, seconds int
+		fmt.Sscan(rest, &most, &seconds)
+		fmt.Fprintf(w, "\nDraw up to %d cards%s, such as draw 135, or just draw to stand pat: ", most, secondsLabel(seconds))
+	case "STATE":
+		// The play-by-play already says what happened
+	case "CARDS":
+		name, hand, _ := strings.Cut(rest, " ")
+		fmt.Fprintf(w, "[%s has %s]\n", name, hand)
+	case "COMMENTATING":
+		fmt.Fprintf(w, "You're commentating, %s seconds behind the table, and will see every hand.\n", rest)
+	case "BUSTED":
+		fmt.Fprintln(w, "You're out of chips. Type join <name> to buy back in.")
+	case "ERROR":
+		fmt.Fprintf(w, "Server: %s\n", rest)
+	case "BYE":
+		fmt.Fprintln(w, "Disconnected.")
+		return true
+	default:
+		fmt.Fprintln(w, line)
+	}
+	return false
+}
+
+// Describe the seconds left to act, or "" with no clock
+func secondsLabel(seconds int) string {
+	if seconds > 0 {
+		return fmt.Sprintf(" (%ds left)", seconds)
+	}
+	return ""
+}
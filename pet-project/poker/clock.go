@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// clockedStrategy is a strategy that can be put on the clock. It gives up and
+// returns the context's error once the context is done.
+type clockedStrategy interface {
+	decideWithin(ctx context.Context, g *game, seat int) (decision, error)
+	discardWithin(ctx context.Context, g *game, seat int) ([]int, error)
+}
+
+// Time a player has left in their time bank
+func (g *game) bankLeft(seat int) time.Duration {
+	if left := g.timeBank - g.players[seat].bankUsed; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// clockFor gives a seat the action timeout plus whatever is left of their time
+// bank. With no timeout set there is no clock.
+func (g *game) clockFor(seat int) (context.Context, context.CancelFunc) {
+	if g.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), g.timeout+g.bankLeft(seat))
+}
+
+// Charge the time taken over the action timeout to the player's time bank
+func (g *game) chargeTime(seat int, started time.Time) {
+	if g.timeout <= 0 {
+		return
+	}
+	if over := time.Since(started) - g.timeout; over > 0 {
+		g.players[seat].bankUsed += over
+		if g.players[seat].bankUsed > g.timeBank {
+			g.players[seat].bankUsed = g.timeBank
+		}
+	}
+}
+
+// decideInTime asks a seat's strategy for a decision, on the clock if it can
+// be timed. A player who runs out of time checks if they can, or folds.
+func (g *game) decideInTime(seat int) decision {
+	p := &g.players[seat]
+	timed, ok := p.strategy.(clockedStrategy)
+	if !ok {
+		return p.strategy.decide(g, seat)
+	}
+
+	ctx, cancel := g.clockFor(seat)
+	defer cancel()
+	started := time.Now()
+	d, err := timed.decideWithin(ctx, g, seat)
+	g.chargeTime(seat, started)
+	if err == nil {
+		return d
+	}
+
+	if g.toCall(seat) == 0 {
+		fmt.Fprintf(g.out, "%s is out of time and checks.\n", p.name)
+		return decision{action: call}
+	}
+	fmt.Fprintf(g.out, "%s is out of time and folds.\n", p.name)
+	return decision{action: fold}
+}
+
+// discardInTime asks a seat's strategy which cards to throw away, standing pat
+// if the player runs out of time
+func (g *game) discardInTime(seat int) []int {
+	p := &g.players[seat]
+	timed, ok := p.strategy.(clockedStrategy)
+	if !ok {
+		return p.strategy.discard(g, seat)
+	}
+
+	ctx, cancel := g.clockFor(seat)
+	defer cancel()
+	started := time.Now()
+	positions, err := timed.discardWithin(ctx, g, seat)
+	g.chargeTime(seat, started)
+	if err != nil {
+		fmt.Fprintf(g.out, "%s is out of time.\n", p.name)
+		return nil
+	}
+	return positions
+}
+
+// Whole seconds a context leaves to act, or 0 with no clock
+func secondsLeft(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return int(time.Until(deadline).Round(time.Second) / time.Second)
+}
+
+// Describe the time a context leaves to act, or "" with no clock
+func timeToAct(ctx context.Context) string {
+	if seconds := secondsLeft(ctx); seconds > 0 {
+		return fmt.Sprintf(" (%ds left)", seconds)
+	}
+	return ""
+}
+
+// lineSource reads lines in the background, so a read can give up when its
+// context is done without losing the line to the next reader
+type lineSource struct {
+	lines chan string
+	err   error // why the lines stopped, once they have
+
+	// edit reads a line in place of the channel, as the full-screen table
+	// does by putting it together from single keys
+	edit func(ctx context.Context) (string, error)
+}
+
+func newLineSource(r io.Reader) *lineSource {
+	return newTokenSource(r, bufio.ScanLines)
+}
+
+// newTokenSource reads whatever split cuts the input into, such as lines or
+// single keys
+func newTokenSource(r io.Reader, split bufio.SplitFunc) *lineSource {
+	src := &lineSource{lines: make(chan string)}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Split(split)
+		for scanner.Scan() {
+			src.lines <- scanner.Text()
+		}
+		src.err = scanner.Err()
+		if src.err == nil {
+			src.err = io.EOF
+		}
+		close(src.lines)
+	}()
+	return src
+}
+
+// readLine waits for the next line or for the context to be done
+func (src *lineSource) readLine(ctx context.Context) (string, error) {
+	if src.edit != nil {
+		return src.edit(ctx)
+	}
+	select {
+	case line, ok := <-src.lines:
+		if !ok {
+			return "", src.err
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+var (
+	stdinOnce  sync.Once
+	stdinLines *lineSource
+)
+
+// consoleInput is the keyboard, shared by every prompt of the console game
+func consoleInput() *lineSource {
+	stdinOnce.Do(func() {
+		stdinLines = newLineSource(os.Stdin)
+	})
+	return stdinLines
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// stalling never makes up its mind, so it always runs out of time
+type stalling struct {
+	simpleBot
+}
+
+func (stalling) decideWithin(ctx context.Context, g *game, seat int) (decision, error) {
+	<-ctx.Done()
+	return decision{}, ctx.Err()
+}
+
+func (stalling) discardWithin(ctx context.Context, g *game, seat int) ([]int, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestOutOfTime(t *testing.T) {
+	g := newTestGame(noLimit)
+	g.out = io.Discard
+	g.players[0].strategy = stalling{}
+	g.timeout = 10 * time.Millisecond
+	g.timeBank = 20 * time.Millisecond
+
+	// Nothing to call once the small blind completes, so the player checks
+	g.commit(0, g.toCall(0), betEntry)
+	if d := g.decideInTime(0); d.action != call {
+		t.Errorf("Expected a check with nothing to call, but got %v", d)
+	}
+	if left := g.bankLeft(0); left != 0 {
+		t.Errorf("Expected the time bank to be used up, but %v is left", left)
+	}
+
+	// Facing a bet, and with the bank gone, the player folds after the timeout alone
+	g.commit(1, 100, betEntry)
+	started := time.Now()
+	if d := g.decideInTime(0); d.action != fold {
+		t.Errorf("Expected a fold facing a bet, but got %v", d)
+	}
+	if took := time.Since(started); took > 15*time.Millisecond+g.timeBank {
+		t.Errorf("Expected the empty time bank to add no time, but the decision took %v", took)
+	}
+}
+
+func TestLineSourceKeepsLinesPastATimeout(t *testing.T) {
+	r, w := io.Pipe()
+	src := newLineSource(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := src.readLine(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the read to time out, but got %v", err)
+	}
+
+	go fmt.Fprintln(w, "3")
+	if line, err := src.readLine(context.Background()); line != "3" || err != nil {
+		t.Errorf("Expected the next read to get the line, but got %q, %v", line, err)
+	}
+	w.Close()
+	if _, err := src.readLine(context.Background()); err != io.EOF {
+		t.Errorf("Expected EOF once the input ends, but got %v", err)
+	}
+}
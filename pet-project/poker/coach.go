@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// The coach keeps every decision the human makes and goes over them once the
+// hand is played, or later from a saved session. For each it estimates the
+// equity the hand had and what folding, calling and raising were worth against
+// a simple range, and points out the choices that gave up a lot.
+
+// Deals played out to judge each decision
+const coachTrials = 2000
+
+// spot is a decision the human faced and what they did, with everything
+// needed to judge it after the hand
+type spot struct {
+	Hand       int    `json:"hand"`
+	Round      string `json:"round"`
+	Cards      deck   `json:"cards"`
+	Opponents  int    `json:"opponents"`
+	Pot        int    `json:"pot"`
+	ToCall     int    `json:"toCall"`
+	Highest    int    `json:"highest"`  // the bet to match
+	Bet        int    `json:"bet"`      // put in already this round
+	MinRaise   int    `json:"minRaise"` // both 0 when raising isn't allowed
+	MaxRaise   int    `json:"maxRaise"`
+	Jokers     int    `json:"jokers,omitempty"`
+	DeucesWild bool   `json:"deucesWild,omitempty"`
+	Bug        bool   `json:"bug,omitempty"`
+	Action     string `json:"action"` // fold, call or raise
+	Amount     int    `json:"amount,omitempty"`
+}
+
+// valid reports whether a saved decision could have come from a table: a
+// hand of five in a betting round, one to seven opponents, no negative chips
+// and a raise range that isn't upside down. Its cards are checked separately.
+func (s spot) valid() bool {
+	switch {
+	case len(s.Cards) != 5, s.Round != preDraw && s.Round != postDraw:
+		return false
+	case s.Opponents < 1 || s.Opponents > maxSeats-1:
+		return false
+	case s.Pot < 0 || s.ToCall < 0 || s.Highest < 0 || s.Bet < 0:
+		return false
+	}
+	return s.MinRaise <= s.MaxRaise
+}
+
+// Names of the actions in a saved session
+var actionNames = []string{fold: "fold", call: "call", raise: "raise"}
+
+// spotAt is the decision a seat faces now
+func (g *game) spotAt(seat int) spot {
+	p := g.players[seat]
+	s := spot{
+		Round:      g.round,
+		Cards:      append(deck{}, p.hand...),
+		Opponents:  g.activeCount() - 1,
+		Pot:        g.pot,
+		ToCall:     g.toCall(seat),
+		Highest:    g.highestBet(),
+		Bet:        p.bet,
+		Jokers:     g.wilds.jokers,
+		DeucesWild: g.wilds.deucesWild,
+		Bug:        g.wilds.bug,
+	}
+	if lo, hi, err := g.raiseBounds(seat); err == nil {
+		s.MinRaise, s.MaxRaise = lo, hi
+	}
+	return s
+}
+
+func (s spot) wilds() wildRules {
+	return wildRules{jokers: s.Jokers, deucesWild: s.DeucesWild, bug: s.Bug}
+}
+
+// equity estimates the share of the pot the hand wins at the showdown against
+// every opponent, each holding a hand from the range. Before the draw every
+// hand draws the standard way first.
+func (s spot) equity(weights []float64, trials int, rng *rand.Rand) float64 {
+	rules := s.wilds()
+	cards := unseen(s.Cards, s.Jokers)
+	stub := make(deck, len(cards))
+	total := 0.0
+	for i := 0; i < trials; i++ {
+		copy(stub, cards)
+		rest := stub
+		hands := []deck{append(deck{}, s.Cards...)}
+		for v := 0; v < s.Opponents; v++ {
+			var villain deck
+			villain, rest = dealFromRange(rest, weights, rng)
+			hands = append(hands, villain)
+		}
+
+		// A full table can run the stub dry, where the dealer would reshuffle
+		// the muck. Here the last to draw just keep what they have.
+		if s.Round == preDraw {
+			for _, hand := range hands {
+				for _, pos := range standardDiscards(hand, rules) {
+					if len(rest) > 0 {
+						hand[pos], rest = rest[0], rest[1:]
+					}
+				}
+			}
+		}
+
+		ranks := make([]handRank, len(hands))
+		best := 0
+		for h, hand := range hands {
+			ranks[h] = evaluateWildHand(hand.toCards(), rules)
+			if compareHands(ranks[h], ranks[best]) > 0 {
+				best = h
+			}
+		}
+		if compareHands(ranks[0], ranks[best]) < 0 {
+			continue
+		}
+		tied := 0
+		for _, r := range ranks {
+			if compareHands(r, ranks[0]) == 0 {
+				tied++
+			}
+		}
+		total += 1 / float64(tied)
+	}
+	return total / float64(trials)
+}
+
+// dealFromRange deals a hand off the stub, picked as often as the range
+// weights its bucket, and returns it with what is left of the stub
+func dealFromRange(stub deck, weights []float64, rng *rand.Rand) (deck, deck) {
+	for {
+		rng.Shuffle(len(stub), func(i, j int) {
+			stub[i], stub[j] = stub[j], stub[i]
+		})
+		if rng.Float64() < weights[handBucket(stub[:5])] {
+			return stub[:5], stub[5:]
+		}
+	}
+}
+
+// callingRange is the part of the plausible range that calls a raise: pairs
+// and better
+func callingRange(round string) []float64 {
+	weights := plausibleRange(round)
+	for b := 0; b < bucketPairOfTwos; b++ {
+		weights[b] = 0
+	}
+	return weights
+}
+
+// foldShare estimates how much of the range folds to a raise
+func (s spot) foldShare(trials int, rng *rand.Rand) float64 {
+	cards := unseen(s.Cards, s.Jokers)
+	weights := plausibleRange(s.Round)
+	folds := 0
+	for i := 0; i < trials; i++ {
+		if hand, _ := dealFromRange(cards, weights, rng); handBucket(hand) < bucketPairOfTwos {
+			folds++
+		}
+	}
+	return float64(folds) / float64(trials)
+}
+
+// verdict is the coach's view of a decision
+type verdict struct {
+	equity  float64
+	evs     []float64 // chips won or lost from here on, by action; no raise when it wasn't allowed
+	raiseTo int       // the raise made, or a pot-sized one when there was none
+	best    actionKind
+	lost    float64 // what the choice gave up against the best one
+	mistake bool
+}
+
+// judge works out what each action was worth. Folding is worth nothing from
+// here on. Calling wins the equity's share of the pot with the call in it.
+// Raising wins the pot at once when everyone folds their draws and bluffs,
+// and is called by pairs and better otherwise.
+func (s spot) judge(trials int, rng *rand.Rand) verdict {
+	v := verdict{equity: s.equity(plausibleRange(s.Round), trials, rng)}
+	v.evs = []float64{0, v.equity*float64(s.Pot+s.ToCall) - float64(s.ToCall)}
+	if s.MaxRaise > 0 {
+		v.raiseTo = s.Amount
+		if s.Action != actionNames[raise] {
+			v.raiseTo = max(s.MinRaise, min(s.Highest+s.Pot+s.ToCall, s.MaxRaise))
+		}
+		cost := v.raiseTo - s.Bet
+		allFold := math.Pow(s.foldShare(trials, rng), float64(s.Opponents))
+		called := s.equity(callingRange(s.Round), trials, rng)
+		won := called*float64(s.Pot+cost+v.raiseTo-s.Highest) - float64(cost)
+		v.evs = append(v.evs, allFold*float64(s.Pot)+(1-allFold)*won)
+	}
+
+	for a, ev := range v.evs {
+		if ev > v.evs[v.best] {
+			v.best = actionKind(a)
+		}
+	}
+	chosen := call
+	for a, name := range actionNames {
+		if name == s.Action {
+			chosen = actionKind(a)
+		}
+	}
+	v.lost = v.evs[v.best] - v.evs[chosen]
+	v.mistake = v.lost >= math.Max(1, float64(s.Pot)/10)
+	return v
+}
+
+// What an action is called in a spot, such as "check" or "raise to 200"
+func (s spot) describe(a actionKind, raiseTo int) string {
+	switch {
+	case a == fold:
+		return "fold"
+	case a == call && s.ToCall == 0:
+		return "check"
+	case a == call:
+		return "call"
+	case s.Highest == 0:
+		return fmt.Sprintf("bet %d", raiseTo)
+	}
+	return fmt.Sprintf("raise to %d", raiseTo)
+}
+
+// coach keeps the human's decisions to go over after each hand
+type coach struct {
+	report   bool      // go over each hand once it's played
+	save     io.Writer // each decision as a line of JSON, nil to keep none
+	rng      *rand.Rand
+	hand     int    // hands played with the human in them
+	pending  []spot // decisions in the hand being played
+	reviewed int    // hand last gone over, for the headings
+
+	hands, judged, mistakes int
+	lost                    float64
+}
+
+func newCoach(report bool, save io.Writer) *coach {
+	return &coach{report: report, save: save, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// record keeps a decision by the human, saving it if the session is saved.
+// It does nothing without a coach.
+func (c *coach) record(g *game, seat int, d decision) {
+	if c == nil || !g.isHuman(seat) {
+		return
+	}
+	s := g.spotAt(seat)
+	s.Hand = c.hand + 1
+	s.Action = actionNames[d.action]
+	if d.action == raise {
+		s.Amount = d.amount
+	}
+	c.pending = append(c.pending, s)
+	if c.save != nil {
+		line, _ := json.Marshal(s)
+		fmt.Fprintf(c.save, "%s\n", line)
+	}
+}
+
+// endHand goes over the human's decisions in the hand just played
+func (c *coach) endHand(g *game) {
+	if c == nil || g.humanSeat() < 0 {
+		return
+	}
+	c.hand++
+	if c.report {
+		for _, s := range c.pending {
+			c.review(g.out, s)
+		}
+	}
+	c.pending = nil
+}
+
+// review judges a decision and explains it
+func (c *coach) review(out io.Writer, s spot) {
+	if s.Hand != c.reviewed {
+		fmt.Fprintf(out, "\n=== Coaching report: hand %d ===\n", s.Hand)
+		c.reviewed = s.Hand
+		c.hands++
+	}
+	v := s.judge(coachTrials, c.rng)
+	c.judged++
+	c.lost += v.lost
+	if v.mistake {
+		c.mistakes++
+	}
+
+	round := strings.ToUpper(s.Round[:1]) + s.Round[1:]
+	rank := evaluateWildHand(s.Cards.toCards(), s.wilds()).rankName
+	if s.ToCall > 0 {
+		fmt.Fprintf(out, "%s, %d to call into a pot of %d, holding %s (%s)\n", round, s.ToCall, s.Pot, s.Cards.toString(), rank)
+	} else {
+		fmt.Fprintf(out, "%s, checked to you with a pot of %d, holding %s (%s)\n", round, s.Pot, s.Cards.toString(), rank)
+	}
+
+	opponents := "1 opponent"
+	if s.Opponents != 1 {
+		opponents = fmt.Sprintf("%d opponents", s.Opponents)
+	}
+	evs := make([]string, len(v.evs))
+	for a, ev := range v.evs {
+		evs[a] = fmt.Sprintf("%s %+.0f", s.describe(actionKind(a), v.raiseTo), ev)
+	}
+	fmt.Fprintf(out, "  Equity ~%.0f%% against %s. Worth in chips: %s\n", 100*v.equity, opponents, strings.Join(evs, ", "))
+
+	chosen := s.describe(call, 0)
+	switch s.Action {
+	case actionNames[fold]:
+		chosen = "fold"
+	case actionNames[raise]:
+		chosen = s.describe(raise, s.Amount)
+	}
+	best := s.describe(v.best, v.raiseTo)
+	switch {
+	case v.mistake:
+		fmt.Fprintf(out, "  You chose to %s. Mistake: to %s was worth about %.0f chips more.\n", chosen, best, v.lost)
+	case v.lost >= 1:
+		fmt.Fprintf(out, "  You chose to %s. Close: to %s was worth about %.0f chips more.\n", chosen, best, v.lost)
+	default:
+		fmt.Fprintf(out, "  You chose to %s. Good: that was the best choice.\n", chosen)
+	}
+}
+
+// summary sums up every decision gone over in the session
+func (c *coach) summary(out io.Writer) {
+	if c == nil || c.judged == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== Coaching summary ===")
+	fmt.Fprintf(out, "Decisions: %d over %d hands\n", c.judged, c.hands)
+	fmt.Fprintf(out, "Clear mistakes: %d\n", c.mistakes)
+	fmt.Fprintf(out, "Chips given up against the best choices: about %.0f, or %.1f a decision\n", c.lost, c.lost/float64(c.judged))
+}
+
+// runCoach is the coach subcommand: it goes over a session saved with
+// -save-session, hand by hand, and sums it up
+func runCoach(args []string) error {
+	flags := flag.NewFlagSet("coach", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: poker coach <saved session>")
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c := newCoach(true, nil)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var s spot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if !s.valid() {
+			return fmt.Errorf("line %d: not a saved decision", line)
+		}
+		for _, c := range s.Cards {
+			if _, err := readCard(c); err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		c.review(os.Stdout, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	c.summary(os.Stdout)
+	return nil
+}
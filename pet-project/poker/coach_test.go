@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJudgeFlagsClearMistakes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	fullHouse := deck{"King of Spades", "King of Hearts", "King of Clubs", "Four of Diamonds", "Four of Spades"}
+	junk := deck{"Two of Spades", "Four of Hearts", "Seven of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	tests := []struct {
+		name    string
+		s       spot
+		best    actionKind
+		mistake bool
+	}{
+		{"folding a full house", spot{Round: postDraw, Cards: fullHouse, Opponents: 1, Pot: 300, ToCall: 100, Highest: 100, MinRaise: 200, MaxRaise: 900, Action: "fold"}, raise, true},
+		{"raising a full house", spot{Round: postDraw, Cards: fullHouse, Opponents: 1, Pot: 300, ToCall: 100, Highest: 100, MinRaise: 200, MaxRaise: 900, Action: "raise", Amount: 500}, raise, false},
+		{"calling off with Jack-high", spot{Round: postDraw, Cards: junk, Opponents: 2, Pot: 600, ToCall: 500, Highest: 500, Action: "call"}, fold, true},
+		{"checking Jack-high", spot{Round: postDraw, Cards: junk, Opponents: 1, Pot: 200, Action: "call"}, call, false},
+	}
+	for _, test := range tests {
+		v := test.s.judge(1000, rng)
+		if v.best != test.best || v.mistake != test.mistake {
+			t.Errorf("%s: expected the best choice to be %s and a mistake %v, but got %s and %v (%v)",
+				test.name, actionNames[test.best], test.mistake, actionNames[v.best], v.mistake, v.evs)
+		}
+	}
+}
+
+func TestCoachGoesOverTheHand(t *testing.T) {
+	var out, saved bytes.Buffer
+	g := newGame()
+	g.out = &out
+	g.coach = newCoach(true, &saved)
+	g.postBlinds()
+	g.dealHands()
+	g.players[0].hand = deck{"Ace of Spades", "Ace of Hearts", "Ace of Clubs", "Ace of Diamonds", "Four of Spades"}
+
+	g.coach.record(g, 1, decision{action: call}) // Not the human
+	g.coach.record(g, 0, decision{action: fold})
+	g.coach.endHand(g)
+	if got := strings.Count(saved.String(), "\n"); got != 1 {
+		t.Fatalf("Expected the human's one decision to be saved, but got %d", got)
+	}
+	for _, want := range []string{"=== Coaching report: hand 1 ===", "Pre-draw, 25 to call into a pot of 75", "(Four of a Kind)", "You chose to fold. Mistake"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the report to include %q, but got:\n%s", want, out.String())
+		}
+	}
+
+	// A saved session can be gone over later
+	var s spot
+	if err := json.Unmarshal(saved.Bytes(), &s); err != nil || s.Hand != 1 || s.Action != "fold" || len(s.Cards) != 5 {
+		t.Errorf("Expected the saved decision to read back, but got %+v, %v", s, err)
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	os.WriteFile(path, saved.Bytes(), 0o644)
+	if err := runCoach([]string{path}); err != nil {
+		t.Errorf("Expected the saved session to be gone over, but got %v", err)
+	}
+	os.WriteFile(path, []byte("{\"hand\": 1}\n"), 0o644)
+	if err := runCoach([]string{path}); err == nil {
+		t.Errorf("Expected a line that isn't a decision to be refused")
+	}
+
+	// Lines no table could have written are refused rather than judged
+	for _, change := range []func(*spot){
+		func(s *spot) { s.Opponents = 12 },
+		func(s *spot) { s.Opponents = 0 },
+		func(s *spot) { s.Pot = -100 },
+		func(s *spot) { s.ToCall = -25 },
+		func(s *spot) { s.Bet = -1 },
+		func(s *spot) { s.MinRaise, s.MaxRaise = 500, 100 },
+	} {
+		bad := s
+		change(&bad)
+		line, _ := json.Marshal(bad)
+		os.WriteFile(path, append(line, '\n'), 0o644)
+		if err := runCoach([]string{path}); err == nil || err.Error() != "line 1: not a saved decision" {
+			t.Errorf("Expected %s to be refused, but got %v", line, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Most cards a player may replace at the draw
+const maxDraw = 3
+
+// drawCards lets every player still in the hand replace up to maxDraw cards,
+// starting with the first player after the dealer
+func (g *game) drawCards() {
+	fmt.Fprintln(g.out, "\n=== Draw ===")
+
+	seat := g.dealer
+	for range g.players {
+		seat = g.nextSeat(seat)
+		p := &g.players[seat]
+		if p.folded {
+			continue
+		}
+
+		positions := validDiscards(g.discardInTime(seat), len(p.hand))
+		replacements := deck{}
+		if g.mental != nil && len(positions) > 0 {
+			replacements = g.mental.draw(g, seat, positions)
+		}
+		for i, pos := range positions {
+			g.muck = append(g.muck, p.hand[pos])
+			if g.mental != nil {
+				p.hand[pos] = replacements[i]
+			} else {
+				p.hand[pos] = g.drawFromDeck()
+			}
+		}
+
+		if len(positions) == 0 {
+			fmt.Fprintf(g.out, "%s stands pat.\n", p.name)
+		} else {
+			fmt.Fprintf(g.out, "%s draws %d cards.\n", p.name, len(positions))
+		}
+		p.drawn = len(positions)
+	}
+	g.round = postDraw
+}
+
+// Drop duplicate or out of range positions and keep at most maxDraw of them
+func validDiscards(positions []int, handSize int) []int {
+	seen := make(map[int]bool)
+	var valid []int
+	for _, pos := range positions {
+		if pos < 0 || pos >= handSize || seen[pos] {
+			continue
+		}
+		seen[pos] = true
+		valid = append(valid, pos)
+	}
+	if len(valid) > maxDraw {
+		valid = valid[:maxDraw]
+	}
+	return valid
+}
+
+// Take the top card, reshuffling the muck into the deck when it runs out
+func (g *game) drawFromDeck() string {
+	if len(g.deck) == 0 {
+		g.deck = g.muck
+		g.muck = deck{}
+		if g.fair != nil {
+			g.deck.shuffleWith(g.fair.rng)
+		} else if g.rng != nil {
+			g.deck.shuffleWith(g.rng)
+		} else {
+			g.deck.shuffle()
+		}
+	}
+	top, remaining := deal(g.deck, 1)
+	g.deck = remaining
+	return top[0]
+}
+
+// Ask the human which cards to throw away
+func (g *game) playerDiscards(ctx context.Context) ([]int, error) {
+	fmt.Fprintf(g.out, "\nEnter the cards to discard, up to %d (e.g. 135 for the 1st, 3rd and 5th),\n", maxDraw)
+	fmt.Fprintf(g.out, "or just press Enter to stand pat%s: ", timeToAct(ctx))
+
+	choice, err := consoleInput().readLine(ctx)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var positions []int
+	for _, r := range strings.TrimSpace(choice) {
+		if r >= '1' && r <= '9' {
+			positions = append(positions, int(r-'1'))
+		}
+	}
+	return positions, nil
+}
+
+func (g *game) computerDiscards(seat int) []int {
+	return standardDiscards(g.players[seat].hand, g.wilds)
+}
+
+// standardDiscards keeps the cards that make up a hand and throws the rest,
+// standing pat on a straight or better
+func standardDiscards(hand deck, rules wildRules) []int {
+	if evaluateWildHand(hand.toCards(), rules).rank >= 5 {
+		return nil
+	}
+
+	cards := hand.toCards()
+	valueCounts := make(map[int]int)
+	suitCounts := make(map[string]int)
+	for _, c := range cards {
+		if !rules.isWild(c) {
+			valueCounts[c.value]++
+			suitCounts[c.suit]++
+		}
+	}
+
+	// Draw to four to a flush
+	for suit, count := range suitCounts {
+		if count == 4 {
+			var positions []int
+			for i, c := range cards {
+				if !rules.isWild(c) && c.suit != suit {
+					positions = append(positions, i)
+				}
+			}
+			return positions
+		}
+	}
+
+	// Keep pairs and better plus wild cards, throwing the lowest of the rest
+	var positions []int
+	for i, c := range cards {
+		if !rules.isWild(c) && valueCounts[c.value] < 2 {
+			positions = append(positions, i)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return cards[positions[i]].value < cards[positions[j]].value
+	})
+	if len(positions) > maxDraw {
+		positions = positions[:maxDraw]
+	}
+	return positions
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"math/rand"
+	"sync"
+)
+
+// env is a reinforcement learning environment over the engine, in the style
+// of Gym: Reset deals a hand and returns the agent's first observation, and
+// Step takes an action and returns the next observation, the reward and
+// whether the hand is over. The agent sits in seat 0 against computers, and
+// every hand starts everyone on the same stack, so hands are independent
+// episodes. The reward comes at the end of the hand: the chips won or lost,
+// in big blinds.
+//
+// The engine plays the hand in its own goroutine, and stops at the agent's
+// seat to hand the decision over, the same way remote seats wait for a player.
+
+// The agent's actions: fold, check or call, four raise sizes, and the draws,
+// one for each choice in drawChoices
+const (
+	actFold = iota
+	actCall
+	actMinRaise
+	actHalfPot // raise by half the pot after calling
+	actPot
+	actAllIn
+	envDrawActions // the first of the draws
+)
+
+// drawChoices are the ways to draw: the positions thrown away, from standing pat up
+var drawChoices = func() [][]int {
+	var choices [][]int
+	for mask := 0; mask < 1<<5; mask++ {
+		if bits.OnesCount(uint(mask)) > maxDraw {
+			continue
+		}
+		var positions []int
+		for pos := 0; pos < 5; pos++ {
+			if mask&(1<<pos) != 0 {
+				positions = append(positions, pos)
+			}
+		}
+		choices = append(choices, positions)
+	}
+	return choices
+}()
+
+// Number of actions the agent chooses among
+var envActions = envDrawActions + len(drawChoices)
+
+// The observation is a fixed-size vector. Chips are counted in starting stacks,
+// and seats start from the agent's and go round the table.
+const (
+	envCardFeatures    = 53 // which of the 52 cards the agent holds, and how many jokers
+	envRoundFeatures   = 3  // betting before the draw, drawing, betting after it
+	envSeatFeatures    = 7  // seated, chips, bet this round, in the pot, folded, dealer, cards drawn
+	envPotFeatures     = 3  // pot, to call, big blind
+	envHistory         = 16 // most recent actions, the latest first
+	envHistoryFeatures = maxSeats + 5
+
+	observationSize = envCardFeatures + envRoundFeatures + maxSeats*envSeatFeatures + envPotFeatures + envHistory*envHistoryFeatures
+)
+
+// Where each card goes in the observation
+var cardIndex = func() map[string]int {
+	index := make(map[string]int)
+	for i, c := range newDeck() {
+		index[c] = i
+	}
+	return index
+}()
+
+// observation is what the agent sees, and which actions it may take.
+// legal is all false once the hand is over.
+type observation struct {
+	features []float32
+	legal    []bool
+}
+
+// envConfig sets up the table an environment plays at
+type envConfig struct {
+	opponents int             // computer opponents, 1 to 7
+	stack     int             // chips everyone has at the start of each hand
+	betting   bettingRules    // no-limit if left empty
+	opponent  func() strategy // plays each opponent, simpleBot when nil
+	out       io.Writer       // the play-by-play, thrown away when nil
+}
+
+// envTurn is the hand stopping for the agent, or ending
+type envTurn struct {
+	obs  observation
+	done bool
+}
+
+// envAction is an action in the history the agent sees
+type envAction struct {
+	seat      int
+	d         decision
+	amount    int // chips the call or raise put in
+	afterDraw bool
+}
+
+type env struct {
+	cfg     envConfig
+	g       *game
+	turns   chan envTurn
+	picks   chan int
+	quit    chan struct{} // closed to fold the agent's hand when it is reset mid-hand
+	playing bool
+	current observation
+	history []envAction
+}
+
+func newEnv(cfg envConfig) (*env, error) {
+	if cfg.opponents < 1 || cfg.opponents > maxSeats-1 {
+		return nil, fmt.Errorf("opponents must be between 1 and %d", maxSeats-1)
+	}
+	if cfg.stack <= 0 {
+		cfg.stack = 1000
+	}
+	if cfg.betting == (bettingRules{}) {
+		cfg.betting = bettingRules{structure: noLimit, smallBet: 50, bigBet: 100, raiseCap: 4}
+	}
+	if cfg.opponent == nil {
+		cfg.opponent = func() strategy { return simpleBot{} }
+	}
+	if cfg.out == nil {
+		cfg.out = io.Discard
+	}
+
+	e := &env{cfg: cfg, turns: make(chan envTurn), picks: make(chan int), quit: make(chan struct{})}
+	e.g = newGame()
+	e.g.addOpponents(cfg.opponents)
+	e.g.betting = cfg.betting
+	e.g.out = cfg.out
+	e.g.players[0].name = "Agent"
+	e.g.players[0].strategy = envSeat{e}
+	for i := 1; i < len(e.g.players); i++ {
+		e.g.players[i].strategy = cfg.opponent()
+	}
+	e.g.rng = rand.New(rand.NewSource(1))
+	return e, nil
+}
+
+// Reset seeds the shuffles and the computers' play, and deals until the agent
+// has a decision to make. A hand in progress is folded and forgotten.
+func (e *env) Reset(seed int64) observation {
+	e.g.rng = rand.New(rand.NewSource(seed))
+	return e.nextHand()
+}
+
+// Step plays one of the agent's legal actions. The reward is only paid once
+// the hand is over.
+func (e *env) Step(action int) (observation, float64, bool, error) {
+	if !e.playing {
+		return e.current, 0, true, errors.New("the hand is over, so reset for another")
+	}
+	if action < 0 || action >= envActions || !e.current.legal[action] {
+		return e.current, 0, false, fmt.Errorf("action %d isn't legal here", action)
+	}
+	e.picks <- action
+	t := <-e.turns
+	e.current = t.obs
+	if !t.done {
+		return t.obs, 0, false, nil
+	}
+	e.playing = false
+	return t.obs, float64(e.g.players[0].chips-e.cfg.stack) / float64(e.g.bigBlind), true, nil
+}
+
+// Close folds a hand in progress, leaving no goroutine behind
+func (e *env) Close() {
+	e.abandon()
+}
+
+// nextHand deals hands until one stops for the agent
+func (e *env) nextHand() observation {
+	e.abandon()
+	for {
+		e.deal()
+		t := <-e.turns
+		e.current = t.obs
+		if !t.done {
+			return t.obs
+		}
+		e.playing = false
+	}
+}
+
+// deal starts a hand with everyone on a full stack and the button anywhere
+func (e *env) deal() {
+	g := e.g
+	g.resetRound()
+	for i := range g.players {
+		g.players[i].chips = e.cfg.stack
+	}
+	g.dealer = g.rng.Intn(len(g.players))
+	e.history = nil
+	e.playing = true
+	go func() {
+		playHand(g)
+		e.turns <- envTurn{obs: observation{features: e.features(false), legal: make([]bool, envActions)}, done: true}
+	}()
+}
+
+// abandon folds the agent out of a hand in progress and waits for it to end
+func (e *env) abandon() {
+	if !e.playing {
+		return
+	}
+	close(e.quit)
+	for t := range e.turns {
+		if t.done {
+			break
+		}
+	}
+	e.quit = make(chan struct{})
+	e.playing = false
+}
+
+// await hands the agent its turn, and waits for the action it picks. It is
+// false when the hand is being abandoned.
+func (e *env) await(seat int, drawing bool) (int, bool) {
+	select {
+	case <-e.quit:
+		return 0, false
+	default:
+	}
+	e.turns <- envTurn{obs: observation{features: e.features(drawing), legal: e.legal(seat, drawing)}}
+	select {
+	case a := <-e.picks:
+		return a, true
+	case <-e.quit:
+		return 0, false
+	}
+}
+
+// legal masks the actions the agent may take
+func (e *env) legal(seat int, drawing bool) []bool {
+	mask := make([]bool, envActions)
+	if drawing {
+		for i := range drawChoices {
+			mask[envDrawActions+i] = true
+		}
+		return mask
+	}
+	for _, a := range e.g.LegalActions(seat) {
+		switch a.action {
+		case fold:
+			mask[actFold] = e.g.toCall(seat) > 0
+		case call:
+			mask[actCall] = true
+		case raise:
+			mask[actMinRaise] = true
+			mask[actHalfPot] = a.max > a.min
+			mask[actPot] = a.max > a.min
+			mask[actAllIn] = a.max > a.min
+		}
+	}
+	return mask
+}
+
+// decision turns one of the agent's betting actions into the engine's
+func (e *env) decision(seat int, action int) decision {
+	g := e.g
+	highest := g.highestBet()
+	pot := g.pot + g.toCall(seat) // the pot once the agent has called
+	switch action {
+	case actFold:
+		return decision{action: fold}
+	case actCall:
+		return decision{action: call}
+	case actHalfPot:
+		return g.clampRaise(seat, highest+pot/2)
+	case actPot:
+		return g.clampRaise(seat, highest+pot)
+	case actAllIn:
+		return g.clampRaise(seat, g.players[seat].chips+g.players[seat].bet)
+	}
+	return g.clampRaise(seat, highest)
+}
+
+// features encodes the table as the agent sees it
+func (e *env) features(drawing bool) []float32 {
+	g := e.g
+	x := make([]float32, observationSize)
+	stack := float32(e.cfg.stack)
+	chips := func(n int) float32 { return float32(n) / stack }
+
+	for _, c := range g.players[0].hand {
+		if i, ok := cardIndex[c]; ok {
+			x[i] = 1
+		} else {
+			x[52]++ // a joker
+		}
+	}
+	off := envCardFeatures
+
+	switch {
+	case g.round == postDraw:
+		x[off+2] = 1
+	case drawing:
+		x[off+1] = 1
+	default:
+		x[off] = 1
+	}
+	off += envRoundFeatures
+
+	for i, p := range g.players {
+		seat := x[off+i*envSeatFeatures:]
+		seat[0] = 1
+		seat[1] = chips(p.chips)
+		seat[2] = chips(p.bet)
+		seat[3] = chips(p.total)
+		if p.folded {
+			seat[4] = 1
+		}
+		if i == g.dealer {
+			seat[5] = 1
+		}
+		seat[6] = float32(p.drawn) / maxDraw
+	}
+	off += maxSeats * envSeatFeatures
+
+	x[off] = chips(g.pot)
+	x[off+1] = chips(g.toCall(0))
+	x[off+2] = chips(g.bigBlind)
+	off += envPotFeatures
+
+	for i := 0; i < envHistory && i < len(e.history); i++ {
+		a := e.history[len(e.history)-1-i]
+		entry := x[off+i*envHistoryFeatures:]
+		entry[a.seat] = 1
+		entry[maxSeats+int(a.d.action)] = 1
+		entry[maxSeats+3] = chips(a.amount)
+		if a.afterDraw {
+			entry[maxSeats+4] = 1
+		}
+	}
+	return x
+}
+
+// envSeat is the agent's seat at the engine's table
+type envSeat struct {
+	e *env
+}
+
+func (s envSeat) decide(g *game, seat int) decision {
+	a, ok := s.e.await(seat, false)
+	if !ok {
+		if g.toCall(seat) == 0 {
+			return decision{action: call}
+		}
+		return decision{action: fold}
+	}
+	return s.e.decision(seat, a)
+}
+
+func (s envSeat) discard(g *game, seat int) []int {
+	a, ok := s.e.await(seat, true)
+	if !ok {
+		return nil
+	}
+	return drawChoices[a-envDrawActions]
+}
+
+// watchAction keeps the history of the hand for the observation
+func (s envSeat) watchAction(g *game, seat int, d decision) {
+	amount := g.toCall(seat)
+	switch d.action {
+	case fold:
+		amount = 0
+	case raise:
+		amount = d.amount - g.players[seat].bet
+	}
+	s.e.history = append(s.e.history, envAction{seat: seat, d: d, amount: amount, afterDraw: g.round == postDraw})
+}
+
+func (s envSeat) watchHandEnd(g *game, showdown bool) {}
+
+// vecEnv steps many environments at once, each in its own goroutine. An
+// environment whose hand ends deals the next one straight away, so the
+// observation returned with done is the first of the new hand.
+type vecEnv struct {
+	envs []*env
+}
+
+func newVecEnv(n int, cfg envConfig) (*vecEnv, error) {
+	v := &vecEnv{}
+	for i := 0; i < n; i++ {
+		e, err := newEnv(cfg)
+		if err != nil {
+			return nil, err
+		}
+		v.envs = append(v.envs, e)
+	}
+	return v, nil
+}
+
+// Reset seeds each environment with the seed plus its index
+func (v *vecEnv) Reset(seed int64) []observation {
+	obs := make([]observation, len(v.envs))
+	v.each(func(i int, e *env) {
+		obs[i] = e.Reset(seed + int64(i))
+	})
+	return obs
+}
+
+// Step plays an action in every environment. Nothing is played unless every
+// action is legal.
+func (v *vecEnv) Step(actions []int) ([]observation, []float64, []bool, error) {
+	if len(actions) != len(v.envs) {
+		return nil, nil, nil, fmt.Errorf("%d actions for %d environments", len(actions), len(v.envs))
+	}
+	for i, e := range v.envs {
+		if a := actions[i]; a < 0 || a >= envActions || !e.current.legal[a] {
+			return nil, nil, nil, fmt.Errorf("environment %d: action %d isn't legal here", i, a)
+		}
+	}
+	obs := make([]observation, len(v.envs))
+	rewards := make([]float64, len(v.envs))
+	done := make([]bool, len(v.envs))
+	v.each(func(i int, e *env) {
+		obs[i], rewards[i], done[i], _ = e.Step(actions[i])
+		if done[i] {
+			obs[i] = e.nextHand()
+		}
+	})
+	return obs, rewards, done, nil
+}
+
+func (v *vecEnv) Close() {
+	v.each(func(i int, e *env) { e.Close() })
+}
+
+func (v *vecEnv) each(f func(i int, e *env)) {
+	var wg sync.WaitGroup
+	for i, e := range v.envs {
+		wg.Add(1)
+		go func(i int, e *env) {
+			defer wg.Done()
+			f(i, e)
+		}(i, e)
+	}
+	wg.Wait()
+}
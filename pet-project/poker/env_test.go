@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// passive checks and calls, and stands pat at the draw
+func passive(obs observation) int {
+	if obs.legal[envDrawActions] {
+		return envDrawActions
+	}
+	return actCall
+}
+
+// randomLegal picks one of the legal actions at random
+func randomLegal(obs observation, rng *rand.Rand) int {
+	var legal []int
+	for a, ok := range obs.legal {
+		if ok {
+			legal = append(legal, a)
+		}
+	}
+	return legal[rng.Intn(len(legal))]
+}
+
+func TestEnvPlaysHands(t *testing.T) {
+	e, err := newEnv(envConfig{opponents: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	rng := rand.New(rand.NewSource(1))
+	total := 0.0
+	for hand := 0; hand < 200; hand++ {
+		obs := e.Reset(int64(hand))
+		for done := false; !done; {
+			if len(obs.features) != observationSize || len(obs.legal) != envActions {
+				t.Fatalf("Expected %d features and %d actions, but got %d and %d", observationSize, envActions, len(obs.features), len(obs.legal))
+			}
+			var reward float64
+			obs, reward, done, err = e.Step(randomLegal(obs, rng))
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += reward
+		}
+		chips := 0
+		for _, p := range e.g.players {
+			chips += p.chips
+		}
+		if chips != 3*1000 {
+			t.Fatalf("Expected the chips to add up to 3000 after hand %d, but got %d", hand, chips)
+		}
+		if want := float64(e.g.players[0].chips-1000) / 50; e.current.legal[actCall] || want != total {
+			t.Fatalf("Expected a reward of %v once the hand is over, but got %v", want, total)
+		}
+		total = 0
+	}
+}
+
+func TestEnvIsReproducible(t *testing.T) {
+	play := func() ([][]float32, []float64) {
+		e, _ := newEnv(envConfig{opponents: 1, betting: bettingRules{structure: fixedLimit, smallBet: 50, bigBet: 100, raiseCap: 4}})
+		defer e.Close()
+		var seen [][]float32
+		var rewards []float64
+		rng := rand.New(rand.NewSource(7))
+		obs := e.Reset(42)
+		for i := 0; i < 100; i++ {
+			seen = append(seen, obs.features)
+			next, reward, done, _ := e.Step(randomLegal(obs, rng))
+			rewards = append(rewards, reward)
+			obs = next
+			if done {
+				obs = e.Reset(int64(i))
+			}
+		}
+		return seen, rewards
+	}
+	seen1, rewards1 := play()
+	seen2, rewards2 := play()
+	if !reflect.DeepEqual(seen1, seen2) || !reflect.DeepEqual(rewards1, rewards2) {
+		t.Errorf("Expected the same seeds and actions to play out the same way")
+	}
+}
+
+func TestEnvRejectsIllegalActions(t *testing.T) {
+	e, _ := newEnv(envConfig{opponents: 1})
+	defer e.Close()
+	// Call until checking is free
+	seed := int64(1)
+	obs := e.Reset(seed)
+	for obs.legal[actFold] || !obs.legal[actCall] {
+		obs, _, _, _ = e.Step(passive(obs))
+		if !e.playing {
+			seed++
+			obs = e.Reset(seed)
+		}
+	}
+	if _, _, _, err := e.Step(actFold); err == nil {
+		t.Errorf("Expected folding when checking is free to be refused")
+	}
+	if _, _, _, err := e.Step(envActions); err == nil {
+		t.Errorf("Expected an action out of range to be refused")
+	}
+
+	// Resetting in the middle of a hand folds it away
+	obs = e.Reset(99)
+	if !e.playing || !obs.legal[actCall] && !obs.legal[envDrawActions] {
+		t.Fatalf("Expected a reset in the middle of a hand to deal a new one")
+	}
+	for e.playing {
+		obs, _, _, _ = e.Step(passive(obs))
+	}
+	if _, _, done, err := e.Step(actCall); err == nil || !done {
+		t.Errorf("Expected a step after the hand is over to be refused")
+	}
+}
+
+func TestVecEnvStepsInParallel(t *testing.T) {
+	v, err := newVecEnv(8, envConfig{opponents: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+	rng := rand.New(rand.NewSource(1))
+	obs := v.Reset(100)
+	hands := 0
+	for step := 0; step < 200; step++ {
+		actions := make([]int, len(obs))
+		for i := range obs {
+			actions[i] = randomLegal(obs[i], rng)
+		}
+		var done []bool
+		obs, _, done, err = v.Step(actions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, d := range done {
+			if d {
+				hands++
+				if !obs[i].legal[actCall] && !obs[i].legal[envDrawActions] {
+					t.Fatalf("Expected a finished environment to deal the next hand")
+				}
+			}
+		}
+	}
+	if hands == 0 {
+		t.Errorf("Expected some hands to finish in 200 steps")
+	}
+	if _, _, _, err := v.Step(make([]int, 3)); err == nil {
+		t.Errorf("Expected the wrong number of actions to be refused")
+	}
+}
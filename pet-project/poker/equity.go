@@ -0,0 +1,220 @@
+package main
+
+import "math/rand"
+
+// Hand buckets group starting hands that play alike before the draw, from weakest to strongest
+var bucketNames = []string{
+	"King-high or worse", "Ace-high", "Four to a straight", "Four to a flush",
+	"Pair of Twos", "Pair of Threes", "Pair of Fours", "Pair of Fives", "Pair of Sixes",
+	"Pair of Sevens", "Pair of Eights", "Pair of Nines", "Pair of Tens", "Pair of Jacks",
+	"Pair of Queens", "Pair of Kings", "Pair of Aces",
+	"Two Pair", "Three of a Kind", "Straight or better",
+}
+
+const (
+	bucketPairOfTwos = 4
+	bucketTwoPair    = 17
+)
+
+// handBucket puts a five card starting hand without wild cards into one of bucketNames
+func handBucket(hand deck) int {
+	rank := evaluateHand(hand.toCards())
+	switch {
+	case rank.rank >= 5:
+		return len(bucketNames) - 1
+	case rank.rank == 4:
+		return bucketTwoPair + 1
+	case rank.rank == 3:
+		return bucketTwoPair
+	case rank.rank == 2:
+		return bucketPairOfTwos + rank.values[0] - 2
+	}
+
+	cards := hand.toCards()
+	suitCounts := make(map[string]int)
+	for _, c := range cards {
+		suitCounts[c.suit]++
+	}
+	for _, count := range suitCounts {
+		if count == 4 {
+			return 3
+		}
+	}
+	if fourInARow(cards) {
+		return 2
+	}
+	if rank.values[0] == 14 {
+		return 1
+	}
+	return 0
+}
+
+// fourInARow reports whether a hand with no pairs holds four consecutive values
+func fourInARow(cards []card) bool {
+	has := make(map[int]bool)
+	for _, c := range cards {
+		has[c.value] = true
+		if c.value == 14 {
+			has[1] = true // Ace also plays low
+		}
+	}
+	for low := 1; low <= 11; low++ {
+		if has[low] && has[low+1] && has[low+2] && has[low+3] {
+			return true
+		}
+	}
+	return false
+}
+
+// Cards of a fresh deck that aren't in any of the given hands, shuffled
+func remainingDeck(rng *rand.Rand, hands ...deck) deck {
+	used := make(map[string]bool)
+	for _, hand := range hands {
+		for _, c := range hand {
+			used[c] = true
+		}
+	}
+	var rest deck
+	for _, c := range newDeck() {
+		if !used[c] {
+			rest = append(rest, c)
+		}
+	}
+	rng.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	return rest
+}
+
+// drawOut replaces a hand's standard discards with cards off the top of the stub
+func drawOut(hand deck, stub deck) (deck, deck) {
+	drawn := make(deck, len(hand))
+	copy(drawn, hand)
+	for _, pos := range standardDiscards(hand, wildRules{}) {
+		drawn[pos] = stub[0]
+		stub = stub[1:]
+	}
+	return drawn, stub
+}
+
+// allInResult plays out an all in before the draw, with both hands drawing the
+// standard way, and returns hero's share of the pot: 1 for a win, 0.5 for a tie
+func allInResult(hero, villain deck, rng *rand.Rand) float64 {
+	stub := remainingDeck(rng, hero, villain)
+	hero, stub = drawOut(hero, stub)
+	villain, _ = drawOut(villain, stub)
+
+	switch compareHands(evaluateHand(hero.toCards()), evaluateHand(villain.toCards())) {
+	case 1:
+		return 1
+	case 0:
+		return 0.5
+	}
+	return 0
+}
+
+// equityVsRandom estimates the share of the pot a hand wins all in before the
+// draw against a random hand
+func equityVsRandom(hand deck, trials int, rng *rand.Rand) float64 {
+	total := 0.0
+	for i := 0; i < trials; i++ {
+		villain, _ := deal(remainingDeck(rng, hand), 5)
+		total += allInResult(hand, villain, rng)
+	}
+	return total / float64(trials)
+}
+
+// equityVsRange estimates the share of the pot a hand wins all in before the
+// draw against a range of buckets weighted by how often each is played
+func equityVsRange(hand deck, weights []float64, trials int, rng *rand.Rand) float64 {
+	total, counted := 0.0, 0.0
+	for i := 0; i < trials; i++ {
+		villain, _ := deal(remainingDeck(rng, hand), 5)
+		weight := weights[handBucket(villain)]
+		if weight == 0 {
+			continue
+		}
+		total += weight * allInResult(hand, villain, rng)
+		counted += weight
+	}
+	if counted == 0 {
+		return equityVsRandom(hand, trials, rng)
+	}
+	return total / counted
+}
+
+// bucketPool holds example hands for every bucket, dealt at random, and how
+// often a random hand lands in each bucket
+type bucketPool struct {
+	hands [][]deck
+	freq  []float64
+}
+
+// newBucketPool deals random hands to measure each bucket and keep examples of it
+func newBucketPool(deals int, rng *rand.Rand) *bucketPool {
+	pool := &bucketPool{hands: make([][]deck, len(bucketNames)), freq: make([]float64, len(bucketNames))}
+	const keep = 2000
+	for i := 0; i < deals; i++ {
+		hand, _ := deal(remainingDeck(rng), 5)
+		bucket := handBucket(hand)
+		pool.freq[bucket]++
+		if len(pool.hands[bucket]) < keep {
+			pool.hands[bucket] = append(pool.hands[bucket], hand)
+		}
+	}
+	for i := range pool.freq {
+		pool.freq[i] /= float64(deals)
+	}
+	return pool
+}
+
+// sample picks a hand from a bucket that shares no cards with the hands in use
+func (pool *bucketPool) sample(bucket int, rng *rand.Rand, inUse ...deck) deck {
+	examples := pool.hands[bucket]
+	for {
+		hand := examples[rng.Intn(len(examples))]
+		if !sharesCards(hand, inUse...) {
+			return hand
+		}
+	}
+}
+
+// Whether a hand has any card in common with the others
+func sharesCards(hand deck, others ...deck) bool {
+	for _, other := range others {
+		for _, a := range hand {
+			for _, b := range other {
+				if a == b {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// bucketEquity estimates the share of the pot each bucket wins all in against each other bucket
+func (pool *bucketPool) bucketEquity(trials int, rng *rand.Rand) [][]float64 {
+	n := len(bucketNames)
+	equity := make([][]float64, n)
+	for a := range equity {
+		equity[a] = make([]float64, n)
+	}
+	for a := 0; a < n; a++ {
+		for b := a; b < n; b++ {
+			if len(pool.hands[a]) == 0 || len(pool.hands[b]) == 0 {
+				equity[a][b], equity[b][a] = 0.5, 0.5
+				continue
+			}
+			total := 0.0
+			for i := 0; i < trials; i++ {
+				hero := pool.sample(a, rng)
+				villain := pool.sample(b, rng, hero)
+				total += allInResult(hero, villain, rng)
+			}
+			equity[a][b] = total / float64(trials)
+			equity[b][a] = 1 - equity[a][b]
+		}
+	}
+	return equity
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+)
+
+// Provably fair dealing. Before each hand is dealt the table publishes a
+// commitment: the SHA-256 of the hand's seed and of the deck order that seed
+// shuffles to. Once the hand is over it reveals the seed, and anyone can
+// shuffle a fresh deck with it, using poker verify, to check the commitment
+// and the cards they were dealt.
+//
+// A hand's seed is the SHA-256 of a server seed and whatever entropy players
+// sent in before the deal. From the second hand on, the hash of each server
+// seed is published a hand ahead, before any of the entropy it is mixed with
+// arrives, so the server can't choose a seed to suit the players' entropy.
+// The first hand's hash only comes out with its commitment, so entropy sent
+// before the first deal has nothing to hold the server to.
+
+// Most entropy mixed into one hand, and the longest piece
+const (
+	maxEntropy       = 16
+	maxEntropyLength = 64
+)
+
+// fairDealer shuffles each hand from a published commitment
+type fairDealer struct {
+	hand       int
+	serverSeed []byte   // for the hand being played
+	next       []byte   // for the next hand, already announced by its hash
+	entropy    []string // sent in for the next deal
+	used       []string // mixed into the hand being played
+	commit     string   // published for the hand being played
+	rng        *mathrand.Rand
+}
+
+func newFairDealer() *fairDealer {
+	return &fairDealer{next: newServerSeed()}
+}
+
+// A random 32 byte server seed
+func newServerSeed() []byte {
+	seed := make([]byte, 32)
+	rand.Read(seed)
+	return seed
+}
+
+// seedSource is a rand.Source drawing its numbers from the SHA-256 of a seed
+// and a counter, so a seed shuffles the same way on every machine
+type seedSource struct {
+	seed    []byte
+	counter uint64
+}
+
+func (s *seedSource) Uint64() uint64 {
+	block := sha256.Sum256(binary.BigEndian.AppendUint64(bytes.Clone(s.seed), s.counter))
+	s.counter++
+	return binary.BigEndian.Uint64(block[:8])
+}
+
+func (s *seedSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed starts the numbers over; the seed itself can't be changed
+func (s *seedSource) Seed(int64) {
+	s.counter = 0
+}
+
+// handSeed mixes the players' entropy into a server seed
+func handSeed(serverSeed []byte, entropy []string) []byte {
+	h := sha256.New()
+	h.Write(serverSeed)
+	for _, e := range entropy {
+		h.Write([]byte{0})
+		h.Write([]byte(e))
+	}
+	return h.Sum(nil)
+}
+
+// commitment is the SHA-256 of a seed and the deck order it shuffles to
+func commitment(seed []byte, d deck) string {
+	h := sha256.New()
+	h.Write(seed)
+	h.Write([]byte(strings.Join(d, ",")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hex SHA-256 of a server seed, as published before its hand
+func seedHash(serverSeed []byte) string {
+	sum := sha256.Sum256(serverSeed)
+	return hex.EncodeToString(sum[:])
+}
+
+// seededDeck shuffles a fresh deck with a hand's seed, returning the deck and
+// the generator the rest of the hand's shuffles draw on
+func seededDeck(seed []byte, jokers int) (deck, *mathrand.Rand) {
+	rng := mathrand.New(&seedSource{seed: seed})
+	d := newDeckWithJokers(jokers)
+	d.shuffleWith(rng)
+	return d, rng
+}
+
+// addEntropy takes a player's contribution to the next shuffle
+func (f *fairDealer) addEntropy(e string) error {
+	e = strings.TrimSpace(e)
+	switch {
+	case e == "":
+		return errors.New("entropy can't be empty")
+	case len(e) > maxEntropyLength:
+		return fmt.Errorf("entropy can be at most %d characters", maxEntropyLength)
+	case len(f.entropy) >= maxEntropy:
+		return errors.New("the next shuffle has all the entropy it can take")
+	}
+	f.entropy = append(f.entropy, e)
+	return nil
+}
+
+// shuffle deals the game a deck from the next committed seed, announcing the
+// commitment before any card is dealt
+func (f *fairDealer) shuffle(g *game) {
+	f.hand++
+	f.serverSeed, f.next = f.next, newServerSeed()
+	f.used, f.entropy = f.entropy, nil
+
+	seed := handSeed(f.serverSeed, f.used)
+	g.deck, f.rng = seededDeck(seed, g.wilds.jokers)
+	f.commit = commitment(seed, g.deck)
+
+	if f.hand == 1 {
+		fmt.Fprintf(g.out, "Server seed hash for hand 1: %s\n", seedHash(f.serverSeed))
+	}
+	fmt.Fprintf(g.out, "Shuffle commitment for hand %d: %s\n", f.hand, f.commit)
+	if len(f.used) > 0 {
+		fmt.Fprintf(g.out, "Entropy mixed into hand %d: %s\n", f.hand, quoteAll(f.used))
+	}
+	fmt.Fprintf(g.out, "Server seed hash for hand %d: %s\n", f.hand+1, seedHash(f.next))
+}
+
+// revealShuffle publishes the seed of the hand just played, with the command
+// to check it
+func (g *game) revealShuffle() {
+	f := g.fair
+	if f == nil || f.hand == 0 {
+		return
+	}
+	command := fmt.Sprintf("poker verify -server-seed %x -commit %s", f.serverSeed, f.commit)
+	if g.wilds.jokers > 0 {
+		command += fmt.Sprintf(" -jokers %d", g.wilds.jokers)
+	}
+	for _, e := range f.used {
+		command += fmt.Sprintf(" -entropy %q", e)
+	}
+	fmt.Fprintf(g.out, "Server seed for hand %d: %x\n", f.hand, f.serverSeed)
+	fmt.Fprintf(g.out, "Check the shuffle with: %s\n", command)
+}
+
+// Strings quoted and separated by commas
+func quoteAll(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// verifyShuffle replays a revealed shuffle, returning the deck in the order it
+// was dealt, or an error if it doesn't match the commitment
+func verifyShuffle(serverSeed []byte, entropy []string, commit string, jokers int) (deck, error) {
+	seed := handSeed(serverSeed, entropy)
+	d, _ := seededDeck(seed, jokers)
+	if got := commitment(seed, d); !strings.EqualFold(got, commit) {
+		return d, fmt.Errorf("the shuffle doesn't match the commitment: it commits to %s", got)
+	}
+	return d, nil
+}
+
+// entropyFlag collects every -entropy given, in order
+type entropyFlag []string
+
+func (e *entropyFlag) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *entropyFlag) Set(value string) error {
+	*e = append(*e, value)
+	return nil
+}
+
+// runVerify is the verify subcommand: it replays a hand's shuffle from its
+// revealed seed and checks it against the commitment made before the deal
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	serverSeedFlag := flags.String("server-seed", "", "server seed revealed after the hand, in hex")
+	commit := flags.String("commit", "", "shuffle commitment published before the hand")
+	jokers := flags.Int("jokers", 0, "jokers in the deck")
+	var entropy entropyFlag
+	flags.Var(&entropy, "entropy", "entropy mixed into the hand, in the order listed (repeat for each)")
+	flags.Parse(args)
+
+	if *serverSeedFlag == "" || *commit == "" {
+		return errors.New("usage: poker verify -server-seed <hex> -commit <hex> [-entropy <text>]...")
+	}
+	serverSeed, err := hex.DecodeString(*serverSeedFlag)
+	if err != nil {
+		return fmt.Errorf("the server seed isn't hex: %v", err)
+	}
+
+	d, err := verifyShuffle(serverSeed, entropy, *commit, *jokers)
+	fmt.Printf("Server seed hash: %s\n", seedHash(serverSeed))
+	fmt.Println("Compare it with the hash published the hand before.")
+	if err != nil {
+		return err
+	}
+	fmt.Println("The shuffle matches the commitment. The deck was dealt in this order:")
+	for i, c := range d {
+		fmt.Printf("%2d. %s\n", i+1, c)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestShuffleWithIsRepeatable(t *testing.T) {
+	first, second := newDeck(), newDeck()
+	first.shuffleWith(rand.New(&seedSource{seed: []byte("seed")}))
+	second.shuffleWith(rand.New(&seedSource{seed: []byte("seed")}))
+	if first.toString() != second.toString() {
+		t.Fatalf("Expected the same seed to shuffle the same way")
+	}
+	if first.toString() == newDeck().toString() {
+		t.Errorf("Expected the deck to be shuffled")
+	}
+
+	seen := map[string]bool{}
+	for _, c := range first {
+		seen[c] = true
+	}
+	if len(seen) != 52 {
+		t.Errorf("Expected all 52 cards once each, but got %d different cards", len(seen))
+	}
+}
+
+func TestFairShuffleVerifies(t *testing.T) {
+	var out bytes.Buffer
+	g := newGame()
+	g.out = &out
+	g.fair = newFairDealer()
+	announced := seedHash(g.fair.next)
+
+	if err := g.fair.addEntropy("lucky 7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.fair.addEntropy(strings.Repeat("x", maxEntropyLength+1)); err == nil {
+		t.Errorf("Expected overlong entropy to be refused")
+	}
+	g.dealHands()
+	if !strings.Contains(out.String(), "Shuffle commitment for hand 1: "+g.fair.commit) {
+		t.Errorf("Expected the commitment to be published before the deal, but got %q", out.String())
+	}
+	g.revealShuffle()
+
+	if got := seedHash(g.fair.serverSeed); got != announced {
+		t.Errorf("Expected the revealed server seed to match the hash announced, %v, but got %v", announced, got)
+	}
+	d, err := verifyShuffle(g.fair.serverSeed, []string{"lucky 7"}, g.fair.commit, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dealt := g.players[0].hand.toString(); d[:5].toString() != dealt {
+		t.Errorf("Expected the first five cards to be %v, but got %v", dealt, d[:5])
+	}
+	if _, err := verifyShuffle(g.fair.serverSeed, nil, g.fair.commit, 0); err == nil {
+		t.Errorf("Expected the shuffle not to verify without the entropy")
+	}
+}
+
+func TestFairDealerPerTable(t *testing.T) {
+	g := newGame()
+	g.players[0].strategy = simpleBot{}
+	g.addOpponents(7)
+	g.fair = newFairDealer()
+	tables := seatTables(g, 2)
+	if tables[0].fair == tables[1].fair || tables[0].fair == g.fair {
+		t.Fatalf("Expected each table to have its own fair dealer")
+	}
+
+	for _, table := range tables {
+		table.out = io.Discard
+		table.dealHands()
+		table.dealHands()
+	}
+	for _, table := range tables {
+		f := table.fair
+		if f.hand != 2 {
+			t.Errorf("Expected %s to have dealt hand 2, but got hand %d", table.name, f.hand)
+		}
+		d, err := verifyShuffle(f.serverSeed, nil, f.commit, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", table.name, err)
+		}
+		if dealt := table.players[0].hand.toString(); d[:5].toString() != dealt {
+			t.Errorf("Expected %s to deal %v first, but got %v", table.name, d[:5], dealt)
+		}
+	}
+}
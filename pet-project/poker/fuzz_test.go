@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+func FuzzReadCard(f *testing.F) {
+	valid := make(map[string]bool)
+	for _, c := range newDeckWithJokers(1) {
+		valid[c] = true
+		f.Add(c)
+	}
+	for _, s := range []string{"", "Ace", "Ace of", " of ", "Ace of Spades of Hearts", "One of Spades", "ace of spades"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := readCard(s)
+		if valid[s] != (err == nil) {
+			t.Fatalf("Expected %q to be read only if a deck holds it, but got %+v (%v)", s, c, err)
+		}
+		if err == nil && !c.isJoker() && (c.value < 2 || c.value > 14) {
+			t.Fatalf("Expected %q to have a value from Two to Ace, but got %d", s, c.value)
+		}
+	})
+}
+
+// handsFrom deals three hands of five different cards, picked by the bytes
+func handsFrom(data []byte) [3]deck {
+	cards := newDeck()
+	var hands [3]deck
+	for i := 0; i < 15; i++ {
+		j := 0
+		if i < len(data) {
+			j = int(data[i]) % len(cards)
+		}
+		hands[i/5] = append(hands[i/5], cards[j])
+		cards = append(cards[:j], cards[j+1:]...)
+	}
+	return hands
+}
+
+// checkHands evaluates the hands the bytes pick and checks compareHands
+// orders them: antisymmetric, transitive, blind to the order of the cards,
+// and never worse for playing deuces wild
+func checkHands(data []byte) error {
+	hands := handsFrom(data)
+	var ranks [3]handRank
+	for i, hand := range hands {
+		ranks[i] = evaluateHand(hand.toCards())
+		if ranks[i].rank < 1 || ranks[i].rank > 10 || ranks[i].rankName == "" {
+			return fmt.Errorf("%s ranks %d (%q)", hand.toString(), ranks[i].rank, ranks[i].rankName)
+		}
+		reversed := deck{hand[4], hand[3], hand[2], hand[1], hand[0]}
+		if compareHands(evaluateHand(reversed.toCards()), ranks[i]) != 0 {
+			return fmt.Errorf("%s ranks differently in another order", hand.toString())
+		}
+		if compareHands(evaluateWildHand(hand.toCards(), wildRules{deucesWild: true}), ranks[i]) < 0 {
+			return fmt.Errorf("%s is worse with deuces wild", hand.toString())
+		}
+	}
+
+	for a := 0; a < 3; a++ {
+		for b := 0; b < 3; b++ {
+			ab := compareHands(ranks[a], ranks[b])
+			if ab != -compareHands(ranks[b], ranks[a]) {
+				return fmt.Errorf("comparing %s and %s isn't antisymmetric", hands[a].toString(), hands[b].toString())
+			}
+			for c := 0; c < 3; c++ {
+				bc, ac := compareHands(ranks[b], ranks[c]), compareHands(ranks[a], ranks[c])
+				if ab >= 0 && bc >= 0 && ac < 0 || ab == 0 && bc == 0 && ac != 0 {
+					return fmt.Errorf("comparing %s, %s and %s isn't transitive", hands[a].toString(), hands[b].toString(), hands[c].toString())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func FuzzEvaluateHand(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{12, 11, 10, 9, 8, 0, 13, 26, 39, 1, 3, 2, 1, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := checkHands(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestCompareHandsIsAnOrder(t *testing.T) {
+	property := func(data [15]byte) bool {
+		err := checkHands(data[:])
+		if err != nil {
+			t.Log(err)
+		}
+		return err == nil
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// randomPlayer takes any of its legal actions at random, raising by a random
+// amount, and draws a random number of cards
+type randomPlayer struct {
+	rng       *rand.Rand
+	decisions int
+	stuck     bool // asked to act with nothing it may do
+}
+
+func (r *randomPlayer) decide(g *game, seat int) decision {
+	r.decisions++
+	legal := g.LegalActions(seat)
+	if len(legal) == 0 {
+		r.stuck = true
+		return decision{action: call}
+	}
+	a := legal[r.rng.Intn(len(legal))]
+	d := decision{action: a.action}
+	if a.action == raise {
+		d.amount = a.min + r.rng.Intn(a.max-a.min+1)
+	}
+	return d
+}
+
+func (r *randomPlayer) discard(g *game, seat int) []int {
+	return r.rng.Perm(5)[:r.rng.Intn(maxDraw+1)]
+}
+
+// playRandomGame sets up a table from the seed, with random stacks, betting,
+// antes, rake and wild cards, and plays up to hands hands of random legal
+// actions. The ledger panics if chips are made or lost within a hand.
+func playRandomGame(seed int64, hands int) error {
+	rng := rand.New(rand.NewSource(seed))
+	g := newGame()
+	g.out = io.Discard
+	g.rng = rng
+	g.addOpponents(1 + rng.Intn(5))
+	player := &randomPlayer{rng: rng}
+	total := 0
+	for i := range g.players {
+		g.players[i].chips = 1 + rng.Intn(3000)
+		g.players[i].strategy = player
+		total += g.players[i].chips
+	}
+	g.betting.structure = bettingStructure(rng.Intn(3))
+	if rng.Intn(2) == 0 {
+		g.ante = 10
+		g.bigBlindAnte = rng.Intn(2) == 0
+	}
+	if rng.Intn(2) == 0 {
+		g.house = newHouse(5, 30, rng.Intn(2) == 0, 0, 0)
+	}
+	if rng.Intn(4) == 0 {
+		g.wilds = wildRules{jokers: rng.Intn(3), deucesWild: rng.Intn(2) == 0}
+	}
+
+	for hand := 0; hand < hands && g.playersWithChips() > 1; hand++ {
+		player.decisions = 0
+		playHand(g)
+		switch {
+		case player.stuck:
+			return fmt.Errorf("hand %d asked a seat to act with no legal actions", hand)
+		case player.decisions > 10000:
+			return fmt.Errorf("hand %d took %d decisions", hand, player.decisions)
+		case g.phase != betweenHands || g.pot != 0:
+			return fmt.Errorf("hand %d ended in the %s with %d in the pot", hand, g.phase, g.pot)
+		}
+		chips := 0
+		for _, p := range g.players {
+			chips += p.chips
+		}
+		if g.house != nil {
+			chips += g.house.rake
+		}
+		if chips != total {
+			return fmt.Errorf("hand %d left %d chips of %d", hand, chips, total)
+		}
+		g.resetRound()
+	}
+	return nil
+}
+
+func FuzzPlayHands(f *testing.F) {
+	for seed := int64(0); seed < 8; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		if err := playRandomGame(seed, 20); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRandomGamesConserveChips(t *testing.T) {
+	for seed := int64(0); seed < 100; seed++ {
+		if err := playRandomGame(seed, 50); err != nil {
+			t.Fatalf("Seed %d: %v", seed, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden transcripts in testdata from the game as it plays now")
+
+// TestMain runs the console game instead of the tests when the golden tests
+// start this binary as the poker command
+func TestMain(m *testing.M) {
+	if os.Getenv("POKER_GOLDEN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Whole seeded sessions, each with the keyboard input typed into them
+var goldenSessions = []struct {
+	name  string
+	args  []string
+	input []string
+}{
+	{
+		// A draw to a showdown, raises the computer folds to, an answer off
+		// the menu and sitting out refused heads-up
+		name:  "heads-up",
+		args:  []string{"-seed", "7"},
+		input: []string{"2", "145", "2", "", "", "9", "1", "300", "", "", "1", "150", "", "sit", "2", "", "2", "quit"},
+	},
+	{
+		// Three-handed with capped raises and a joker shown down
+		name:  "fixed-limit-jokers",
+		args:  []string{"-seed", "11", "-opponents", "2", "-limit", "fixed-limit", "-jokers", "1"},
+		input: []string{"1", "2", "2", "345", "1", "2", "", "", "2", "4", "2", "", "3", "quit"},
+	},
+	{
+		name:  "advisor-and-coach",
+		args:  []string{"-seed", "3", "-advisor", "-coach"},
+		input: []string{"2", "124", "1", "200", "2", "", "3", "quit"},
+	},
+	{
+		name:  "spectate",
+		args:  []string{"-seed", "5", "-spectate", "-opponents", "3"},
+		input: []string{"", "", "", "quit"},
+	},
+	{
+		// Two tournament tables played at once, a knockout, then folding
+		// until a table breaks and the final table is announced
+		name:  "multi-table",
+		args:  []string{"-seed", "13", "-tournament", "-tables", "2", "-opponents", "5", "-seats", "4", "-hands-per-level", "2"},
+		input: []string{"1", "1000", "2", "", "", "3", "3", "3", "3", "3", "3", "3", "3", "3", "3", "3", "3", "3", "quit"},
+	},
+	{
+		// An all in called, to the end of the game
+		name:  "all-in",
+		args:  []string{"-seed", "9"},
+		input: []string{"1", "1000", ""},
+	},
+}
+
+// play runs the poker command with the arguments and input, and returns what
+// it printed
+func play(t *testing.T, args []string, input string) []byte {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "POKER_GOLDEN=1")
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("poker %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out
+}
+
+// firstDifference describes the first line where two transcripts part ways
+func firstDifference(want, got []byte) string {
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Sprintf("line %d\n  want: %q\n  got:  %q", i+1, w, g)
+		}
+	}
+	return "nowhere"
+}
+
+func TestGoldenTranscripts(t *testing.T) {
+	for _, session := range goldenSessions {
+		t.Run(session.name, func(t *testing.T) {
+			got := play(t, session.args, strings.Join(session.input, "\n")+"\n")
+			path := filepath.Join("testdata", session.name+".golden")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run the test with -update to record it)", err)
+			}
+			if !bytes.Equal(want, got) {
+				t.Errorf("Expected poker %s to play as recorded in %s, but it differs at %s\n(run the test with -update if the change is intended)",
+					strings.Join(session.args, " "), path, firstDifference(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// handPhase is where a hand is in its life, from the blinds to the payout
+type handPhase int
+
+const (
+	betweenHands handPhase = iota
+	blindsPhase
+	dealPhase
+	preDrawBetting
+	drawPhase
+	postDrawBetting
+	showdownPhase
+	payoutPhase
+)
+
+func (p handPhase) String() string {
+	switch p {
+	case blindsPhase:
+		return "blinds"
+	case dealPhase:
+		return "deal"
+	case preDrawBetting:
+		return "pre-draw betting"
+	case drawPhase:
+		return "draw"
+	case postDrawBetting:
+		return "post-draw betting"
+	case showdownPhase:
+		return "showdown"
+	case payoutPhase:
+		return "payout"
+	}
+	return "between hands"
+}
+
+// The phases each phase may move on to. Betting goes straight to the payout
+// when everyone but one player folds.
+var phaseTransitions = map[handPhase][]handPhase{
+	betweenHands:    {blindsPhase},
+	blindsPhase:     {dealPhase},
+	dealPhase:       {preDrawBetting},
+	preDrawBetting:  {drawPhase, payoutPhase},
+	drawPhase:       {postDrawBetting},
+	postDrawBetting: {showdownPhase, payoutPhase},
+	showdownPhase:   {payoutPhase},
+	payoutPhase:     {betweenHands},
+}
+
+// transitionError is a move between phases that a hand can't make
+type transitionError struct {
+	from, to handPhase
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("a hand can't go from the %s to the %s", e.from, e.to)
+}
+
+// advance moves the hand on to the next phase, if it may go there
+func (g *game) advance(to handPhase) error {
+	for _, next := range phaseTransitions[g.phase] {
+		if next == to {
+			g.phase = to
+			return nil
+		}
+	}
+	return &transitionError{from: g.phase, to: to}
+}
+
+// step plays the hand's current phase and returns the phase that follows it
+func (g *game) step() handPhase {
+	switch g.phase {
+	case blindsPhase:
+		g.clearHand()
+		g.stats.startHand(g)
+		g.postBlinds()
+		return dealPhase
+	case dealPhase:
+		g.dealHands()
+		g.showPlayerHand()
+		return preDrawBetting
+	case preDrawBetting:
+		if g.bettingRound() {
+			return drawPhase
+		}
+		return payoutPhase
+	case drawPhase:
+		g.drawCards()
+		g.showPlayerHand()
+		return postDrawBetting
+	case postDrawBetting:
+		if g.bettingRound() {
+			return showdownPhase
+		}
+		return payoutPhase
+	case showdownPhase:
+		g.showdown()
+		return payoutPhase
+	case payoutPhase:
+		g.payout()
+	}
+	return betweenHands
+}
+
+// payout gives an uncontested pot to the last player in it, then settles up
+// the hand; a showdown has already split the pots between the winners
+func (g *game) payout() {
+	winner := g.lastPlayerStanding()
+	if winner >= 0 {
+		g.takeRake()
+		fmt.Fprintf(g.out, "Everyone else folded. %s wins the pot of %d chips!\n", g.players[winner].name, g.pot)
+		if you := g.humanSeat(); you >= 0 && winner != you && g.players[you].total > 0 {
+			fmt.Fprintf(g.out, "You lost %d chips from your bets/blinds.\n", g.players[you].total)
+		}
+		g.pay(awardEntry, winner, g.pot)
+	}
+	g.auditPayout()
+	g.revealShuffle()
+	g.checkDeal()
+
+	// Show chip counts after hand
+	fmt.Fprintf(g.out, "\nChip counts after hand - %s\n", g.chipCounts())
+	g.coach.endHand(g)
+	g.stats.endHand(g, winner < 0)
+	g.watchHandEnd(winner < 0)
+}
+
+// legalAction is something a seat may do now. For a call min and max are the
+// chips it costs, and for a raise they are the smallest and largest total bet.
+type legalAction struct {
+	action   actionKind
+	min, max int
+}
+
+// Errors for actions a seat can't take
+var (
+	errNotInHand     = errors.New("you aren't in the hand")
+	errUnknownAction = errors.New("there is no such action")
+)
+
+// actionError is a decision the rules don't allow. It unwraps to the reason,
+// such as errRaiseTooSmall.
+type actionError struct {
+	seat     int
+	decision decision
+	reason   error
+}
+
+func (e *actionError) Error() string {
+	return e.reason.Error()
+}
+
+func (e *actionError) Unwrap() error {
+	return e.reason
+}
+
+// LegalActions lists what a seat may do, with the amounts each allows. It is
+// empty for a seat that has folded or is all in.
+func (g *game) LegalActions(seat int) []legalAction {
+	p := g.players[seat]
+	if p.folded || p.chips == 0 {
+		return nil
+	}
+	amount := g.toCall(seat)
+	legal := []legalAction{{action: fold}, {action: call, min: amount, max: amount}}
+	if minTotal, maxTotal, err := g.raiseBounds(seat); err == nil {
+		legal = append(legal, legalAction{action: raise, min: minTotal, max: maxTotal})
+	}
+	return legal
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestHandPhasesOnlyMoveForward(t *testing.T) {
+	g := newGame()
+	for _, phase := range []handPhase{blindsPhase, dealPhase, preDrawBetting, drawPhase, postDrawBetting, showdownPhase, payoutPhase, betweenHands} {
+		if err := g.advance(phase); err != nil {
+			t.Fatalf("Expected to move on to the %s, but got %v", phase, err)
+		}
+	}
+
+	// Skipping the deal, or going back a round, is refused and leaves the hand where it was
+	g.advance(blindsPhase)
+	var te *transitionError
+	if err := g.advance(preDrawBetting); !errors.As(err, &te) || te.from != blindsPhase || te.to != preDrawBetting {
+		t.Errorf("Expected a transition error from the blinds to the betting, but got %v", err)
+	}
+	if g.phase != blindsPhase {
+		t.Errorf("Expected a refused move to leave the hand in the blinds, but it is in the %s", g.phase)
+	}
+	g.phase = postDrawBetting
+	if err := g.advance(drawPhase); err == nil {
+		t.Errorf("Expected the post-draw betting not to go back to the draw")
+	}
+}
+
+func TestPlayHandEndsBetweenHands(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.players[0].strategy = &scripted{decisions: []decision{{action: fold}}}
+	playHand(g)
+	if g.phase != betweenHands {
+		t.Errorf("Expected the hand to finish between hands, but it is in the %s", g.phase)
+	}
+	if g.players[0].chips+g.players[1].chips != 2000 {
+		t.Errorf("Expected the pot to be paid out, but the stacks are %d and %d", g.players[0].chips, g.players[1].chips)
+	}
+	playHand(g) // and the next one can start
+}
+
+func TestLegalActions(t *testing.T) {
+	g := newTestGame(noLimit)
+	want := []legalAction{{action: fold}, {action: call, min: 25, max: 25}, {action: raise, min: 100, max: 1000}}
+	if got := g.LegalActions(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v for the small blind, but got %+v", want, got)
+	}
+
+	g = newTestGame(fixedLimit)
+	g.raises = g.betting.raiseCap
+	want = []legalAction{{action: fold}, {action: call, min: 25, max: 25}}
+	if got := g.LegalActions(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected no raise at the cap, but got %+v", got)
+	}
+
+	// A short stack calls for what it has, and may only go all in
+	g = newTestGame(noLimit)
+	g.players[0].chips = 60
+	want = []legalAction{{action: fold}, {action: call, min: 25, max: 25}, {action: raise, min: 85, max: 85}}
+	if got := g.LegalActions(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected an all in for the short stack, but got %+v", got)
+	}
+
+	g.players[1].folded = true
+	if got := g.LegalActions(1); got != nil {
+		t.Errorf("Expected no actions for a folded seat, but got %+v", got)
+	}
+}
+
+func TestValidateReturnsActionErrors(t *testing.T) {
+	g := newTestGame(noLimit)
+	var ae *actionError
+	if err := g.validate(0, decision{action: raise, amount: 60}); !errors.As(err, &ae) || !errors.Is(err, errRaiseTooSmall) || ae.seat != 0 {
+		t.Errorf("Expected an action error for a raise below the minimum, but got %v", err)
+	}
+	if err := g.validate(0, decision{action: actionKind(7)}); !errors.Is(err, errUnknownAction) {
+		t.Errorf("Expected an unknown action to be refused, but got %v", err)
+	}
+	g.players[1].folded = true
+	if err := g.validate(1, decision{action: call}); !errors.Is(err, errNotInHand) {
+		t.Errorf("Expected a folded seat not to act, but got %v", err)
+	}
+	if err := g.validate(0, decision{action: raise, amount: 100}); err != nil {
+		t.Errorf("Expected a minimum raise to be allowed, but got %v", err)
+	}
+}
+
+func TestParseChoice(t *testing.T) {
+	for choice, want := range map[string]actionKind{"1": raise, "2": call, "3": fold} {
+		if got, err := parseChoice(choice); err != nil || got != want {
+			t.Errorf("Expected %q to be %v, but got %v (%v)", choice, want, got, err)
+		}
+	}
+	var ce *choiceError
+	if _, err := parseChoice("4"); !errors.As(err, &ce) {
+		t.Errorf("Expected a choice off the menu to be refused, but got %v", err)
+	}
+}
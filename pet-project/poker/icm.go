@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// icmEquity returns each player's share of the prize money under the
+// Independent Chip Model: a player finishes first with a chance equal to their
+// share of the chips, and the places below are filled the same way from the
+// players left. Players without chips get nothing.
+func icmEquity(stacks []int, prizes []int) []float64 {
+	equity := make([]float64, len(stacks))
+	total := 0
+	for _, s := range stacks {
+		total += s
+	}
+
+	var place func(taken []bool, left int, chance float64, position int)
+	place = func(taken []bool, left int, chance float64, position int) {
+		if position >= len(prizes) || left == 0 {
+			return
+		}
+		for i, s := range stacks {
+			if taken[i] || s == 0 {
+				continue
+			}
+			p := chance * float64(s) / float64(left)
+			equity[i] += p * float64(prizes[position])
+			taken[i] = true
+			place(taken, left-s, p, position+1)
+			taken[i] = false
+		}
+	}
+	place(make([]bool, len(stacks)), total, 1, 0)
+	return equity
+}
+
+// chipChop splits the prizes by chip count: everyone is guaranteed the lowest
+// prize still to be paid and the rest is shared in proportion to their stacks
+func chipChop(stacks []int, prizes []int) []float64 {
+	pool, floor := 0, 0
+	for i, p := range prizes {
+		if i < len(stacks) {
+			pool += p
+		}
+	}
+	if len(prizes) >= len(stacks) {
+		floor = prizes[len(stacks)-1]
+	}
+
+	total := 0
+	for _, s := range stacks {
+		total += s
+	}
+	shares := make([]float64, len(stacks))
+	rest := float64(pool - floor*len(stacks))
+	for i, s := range stacks {
+		shares[i] = float64(floor) + rest*float64(s)/float64(total)
+	}
+	return shares
+}
+
+// Read a comma separated list of whole numbers, such as "5000,3000,2000"
+func parseAmounts(text string) ([]int, error) {
+	var amounts []int
+	for _, part := range strings.Split(text, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not an amount", part)
+		}
+		amounts = append(amounts, n)
+	}
+	return amounts, nil
+}
+
+// runICM is the icm subcommand: it prints every player's prize equity for a
+// set of stacks, along with what an ICM deal and a chip chop would pay
+func runICM(args []string) error {
+	flags := flag.NewFlagSet("icm", flag.ExitOnError)
+	stacksFlag := flags.String("stacks", "", "chip stacks of the players left, such as 5000,3000,2000")
+	prizesFlag := flags.String("prizes", "", "prize for each place still to be paid, such as 500,300,200")
+	flags.Parse(args)
+
+	if *stacksFlag == "" || *prizesFlag == "" {
+		return errors.New("usage: poker icm -stacks 5000,3000,2000 -prizes 500,300,200")
+	}
+	stacks, err := parseAmounts(*stacksFlag)
+	if err != nil {
+		return err
+	}
+	prizes, err := parseAmounts(*prizesFlag)
+	if err != nil {
+		return err
+	}
+	if len(stacks) > 10 {
+		return errors.New("the ICM calculator handles up to 10 players")
+	}
+
+	total := 0
+	for _, s := range stacks {
+		total += s
+	}
+	if total == 0 {
+		return errors.New("somebody needs to have chips")
+	}
+
+	equity := icmEquity(stacks, prizes)
+	chop := chipChop(stacks, prizes)
+
+	fmt.Println("=== Independent Chip Model ===")
+	fmt.Printf("%-8s %10s %8s %12s %12s\n", "Player", "Chips", "Chips %", "ICM deal", "Chip chop")
+	for i, s := range stacks {
+		fmt.Printf("%-8d %10d %7.1f%% %12.2f %12.2f\n", i+1, s, 100*float64(s)/float64(total), equity[i], chop[i])
+	}
+	return nil
+}
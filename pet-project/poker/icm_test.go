@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestICMEquity(t *testing.T) {
+	prizes := []int{500, 300, 200}
+	equity := icmEquity([]int{5000, 3000, 2000}, prizes)
+
+	total := 0.0
+	for _, e := range equity {
+		total += e
+	}
+	if math.Abs(total-1000) > 1e-6 {
+		t.Errorf("Expected the equities to add up to the 1000 prize pool, but got %v", total)
+	}
+	if math.Abs(equity[0]-383.93) > 0.01 {
+		t.Errorf("Expected the chip leader to be worth 383.93, but got %v", equity[0])
+	}
+
+	even := icmEquity([]int{1500, 1500, 0}, prizes)
+	if even[0] != even[1] || even[0] != 400 || even[2] != 0 {
+		t.Errorf("Expected equal stacks to split 1st and 2nd and a busted player to get nothing, but got %v", even)
+	}
+}
+
+func TestChipChop(t *testing.T) {
+	chop := chipChop([]int{6000, 2000, 2000}, []int{500, 300, 200})
+
+	// Everyone is guaranteed 200 and the other 400 goes by chip count
+	want := []float64{440, 280, 280}
+	for i := range want {
+		if math.Abs(chop[i]-want[i]) > 1e-6 {
+			t.Errorf("Expected a chip chop of %v, but got %v", want, chop)
+			break
+		}
+	}
+}
+
+func TestEquityVsRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	aces := deck{"Ace of Spades", "Ace of Hearts", "Ace of Clubs", "Seven of Diamonds", "Two of Spades"}
+	junk := deck{"Two of Hearts", "Four of Clubs", "Seven of Spades", "Nine of Diamonds", "Jack of Clubs"}
+
+	if eq := equityVsRandom(aces, 2000, rng); eq < 0.8 {
+		t.Errorf("Expected trip Aces to win at least 80%% against a random hand, but got %v", eq)
+	}
+	if eq := equityVsRandom(junk, 2000, rng); eq > 0.4 {
+		t.Errorf("Expected Jack-high to win under 40%% against a random hand, but got %v", eq)
+	}
+}
+
+func TestSolvePushFold(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pool := newBucketPool(20000, rng)
+	equity := pool.bucketEquity(100, rng)
+
+	short, _ := solvePushFold(1, equity, pool.freq, 500)
+	deep, deepCall := solvePushFold(20, equity, pool.freq, 500)
+	shortShare, _ := rangeSummary(short, pool.freq)
+	deepShare, _ := rangeSummary(deep, pool.freq)
+
+	if shortShare < deepShare {
+		t.Errorf("Expected to push more hands with 1BB than 20BB, but pushed %v and %v", shortShare, deepShare)
+	}
+	best := len(bucketNames) - 1
+	if deep[best] < 0.99 || deepCall[best] < 0.99 {
+		t.Errorf("Expected a straight or better to always push and call, but got %v and %v", deep[best], deepCall[best])
+	}
+}
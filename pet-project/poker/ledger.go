@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ledgerKind is why chips moved
+type ledgerKind int
+
+const (
+	blindEntry  ledgerKind = iota // blinds, straddles and live missed blinds
+	anteEntry                     // antes and dead blinds
+	betEntry                      // calls, bets and raises
+	refundEntry                   // an uncalled bet given back
+	awardEntry                    // a pot, or a share of one, paid to a winner
+	rakeEntry                     // the house's cut of a pot
+	timeEntry                     // a player's charge for time at the table
+)
+
+var ledgerKindNames = [...]string{"blind", "ante", "bet", "refund", "award", "rake", "time"}
+
+func (k ledgerKind) String() string {
+	return ledgerKindNames[k]
+}
+
+// Ledger accounts besides the seats, which are their seat numbers
+const (
+	potAccount   = -1
+	houseAccount = -2
+)
+
+// ledgerEntry is one movement of chips between two accounts
+type ledgerEntry struct {
+	kind     ledgerKind
+	phase    handPhase
+	from, to int
+	amount   int
+}
+
+// ledger records every chip that moves in a hand, so a move that makes or
+// loses chips is caught where it happens rather than hands later
+type ledger struct {
+	open    bool
+	total   int // stacks plus pot when the hand began
+	house   int // taken by the house this hand
+	entries []ledgerEntry
+}
+
+// potSize is the most the pot held this hand: everything put in, less refunds
+func (l *ledger) potSize() int {
+	size := 0
+	for _, e := range l.entries {
+		switch {
+		case e.to == potAccount:
+			size += e.amount
+		case e.kind == refundEntry:
+			size -= e.amount
+		}
+	}
+	return size
+}
+
+// ledgerError is a hand that made or lost chips, or left some in the pot,
+// with the table as it stood
+type ledgerError struct {
+	after string // the move or step that broke the count
+	want  int
+	got   int
+	left  int // chips the payout left in the pot
+	dump  string
+}
+
+func (e *ledgerError) Error() string {
+	if e.left != 0 {
+		return fmt.Sprintf("%d chips left in the pot after %s\n%s", e.left, e.after, e.dump)
+	}
+	return fmt.Sprintf("chips not conserved after %s: %d at the start of the hand, %d now\n%s", e.after, e.want, e.got, e.dump)
+}
+
+// openLedger starts the hand's ledger with the chips on the table
+func (g *game) openLedger() {
+	g.ledger = ledger{open: true, total: g.chipsInPlay()}
+}
+
+// Every stack plus the pot, which with the house's takings makes the hand's total
+func (g *game) chipsInPlay() int {
+	total := g.pot
+	for _, p := range g.players {
+		total += p.chips
+	}
+	return total
+}
+
+// stake moves chips from a seat's stack into the pot
+func (g *game) stake(kind ledgerKind, seat int, amount int) {
+	g.move(kind, seat, potAccount, amount)
+}
+
+// pay moves chips from the pot onto a seat's stack
+func (g *game) pay(kind ledgerKind, seat int, amount int) {
+	g.move(kind, potAccount, seat, amount)
+}
+
+// move takes chips from one account and gives them to another, the only way
+// chips change hands during a hand
+func (g *game) move(kind ledgerKind, from, to int, amount int) {
+	if !g.ledger.open {
+		g.openLedger()
+	}
+	*g.balance(from) -= amount
+	*g.balance(to) += amount
+	g.ledger.entries = append(g.ledger.entries, ledgerEntry{kind: kind, phase: g.phase, from: from, to: to, amount: amount})
+
+	payer := from
+	if from == potAccount {
+		payer = to
+	}
+	g.audit(fmt.Sprintf("%s's %s of %d", g.accountName(payer), kind, amount))
+}
+
+func (g *game) balance(account int) *int {
+	switch account {
+	case potAccount:
+		return &g.pot
+	case houseAccount:
+		return &g.ledger.house
+	}
+	return &g.players[account].chips
+}
+
+func (g *game) accountName(account int) string {
+	switch account {
+	case potAccount:
+		return "the pot"
+	case houseAccount:
+		return "the house"
+	}
+	return g.players[account].name
+}
+
+// audit checks that no chips have been made or lost since the hand began,
+// and that no stack or the pot has gone below zero. It panics with a
+// *ledgerError if they have, since the hand can't be trusted to go on.
+func (g *game) audit(after string) {
+	if !g.ledger.open {
+		return
+	}
+	broken := g.pot < 0
+	for _, p := range g.players {
+		broken = broken || p.chips < 0
+	}
+	if got := g.chipsInPlay() + g.ledger.house; broken || got != g.ledger.total {
+		panic(&ledgerError{after: after, want: g.ledger.total, got: got, dump: g.ledgerDump()})
+	}
+}
+
+// auditPayout checks the payout emptied the pot. Chips left there still count
+// towards the hand's total, so audit can't see them, but they would be lost
+// when the pot is cleared for the next hand. It panics with a *ledgerError.
+func (g *game) auditPayout() {
+	if g.pot != 0 {
+		panic(&ledgerError{after: "the payout", left: g.pot, dump: g.ledgerDump()})
+	}
+}
+
+// ledgerDump describes the table and every move in the ledger
+func (g *game) ledgerDump() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s, %s round, pot %d, house %d\n", g.phase, g.round, g.pot, g.ledger.house)
+	for i, p := range g.players {
+		status := ""
+		if p.folded {
+			status = ", folded"
+		}
+		fmt.Fprintf(&b, "  seat %d %s: %d chips, %d bet, %d in the hand%s\n", i, p.name, p.chips, p.bet, p.total, status)
+	}
+	fmt.Fprintf(&b, "ledger:\n")
+	for _, e := range g.ledger.entries {
+		fmt.Fprintf(&b, "  %-17s %-6s %4d from %s to %s\n", e.phase, e.kind, e.amount, g.accountName(e.from), g.accountName(e.to))
+	}
+	return b.String()
+}
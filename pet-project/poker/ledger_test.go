@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLedgerRecordsEveryMove(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.players[0].chips = 300
+	// You shove over the top, the computer calls and has the rest handed back
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 300}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 600}}}
+	playHand(g)
+
+	var kinds []string
+	for _, e := range g.ledger.entries {
+		kinds = append(kinds, e.kind.String())
+	}
+	if got := strings.Join(kinds, " "); !strings.HasPrefix(got, "blind blind bet bet refund award") {
+		t.Errorf("Expected the blinds, both bets, a refund and the award, but got %s", got)
+	}
+	if g.pot != 0 || g.ledger.potSize() != 600 {
+		t.Errorf("Expected a pot of 600 to be paid out in full, but %d of %d is left", g.pot, g.ledger.potSize())
+	}
+	if total := g.players[0].chips + g.players[1].chips; total != 1300 {
+		t.Errorf("Expected 1300 chips between the players, but got %d", total)
+	}
+}
+
+func TestFoldedPotIsPaidOut(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.players[0].strategy = &scripted{decisions: []decision{{action: fold}}}
+	playHand(g)
+
+	// The uncalled half of the big blind goes back, and the rest is won
+	entries := g.ledger.entries
+	refund, award := entries[len(entries)-2], entries[len(entries)-1]
+	if refund.kind != refundEntry || refund.from != potAccount || refund.to != 1 || refund.amount != 25 {
+		t.Errorf("Expected the computer's uncalled 25 to be refunded, but got %+v", refund)
+	}
+	if award.kind != awardEntry || award.to != 1 || award.amount != 50 || award.phase != payoutPhase {
+		t.Errorf("Expected the computer to be awarded the blinds at the payout, but got %+v", award)
+	}
+	if g.pot != 0 {
+		t.Errorf("Expected the pot to be empty once it is won, but it has %d", g.pot)
+	}
+}
+
+func TestLedgerCatchesMadeChips(t *testing.T) {
+	g := newTestGame(noLimit)
+	g.out = io.Discard
+	g.players[1].chips += 10 // chips from nowhere
+
+	defer func() {
+		var le *ledgerError
+		err, _ := recover().(error)
+		if !errors.As(err, &le) || le.want != 2000 || le.got != 2010 {
+			t.Fatalf("Expected a ledger error for the 10 extra chips, but got %v", err)
+		}
+		for _, want := range []string{"You's bet of 25", "seat 1 Computer: 960 chips", "blind    50 from Computer to the pot"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected the dump to include %q, but got:\n%v", want, err)
+			}
+		}
+	}()
+	g.commit(0, 25, betEntry)
+}
+
+func TestLedgerCatchesChipsLeftInThePot(t *testing.T) {
+	g := newTestGame(noLimit)
+	g.out = io.Discard
+	g.phase = payoutPhase // with both players in and no showdown to split the pot
+
+	defer func() {
+		var le *ledgerError
+		err, _ := recover().(error)
+		if !errors.As(err, &le) || le.left != 75 {
+			t.Fatalf("Expected a ledger error for the 75 chips left in the pot, but got %v", err)
+		}
+		if !strings.HasPrefix(err.Error(), "75 chips left in the pot after the payout") {
+			t.Errorf("Expected the error to say what was left, but got:\n%v", err)
+		}
+	}()
+	g.payout()
+}
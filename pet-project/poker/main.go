@@ -1,85 +1,386 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"strings"
+	"time"
 )
 
+// consolePlayer is the human at the keyboard
+type consolePlayer struct{}
+
+func (c consolePlayer) decide(g *game, seat int) decision {
+	d, _ := c.decideWithin(context.Background(), g, seat)
+	return d
+}
+
+func (consolePlayer) decideWithin(ctx context.Context, g *game, seat int) (decision, error) {
+	if g.advisor {
+		g.showAdvice(seat)
+	}
+	
+	// The full-screen table has buttons in place of the numbered menu
+	if g.screen != nil {
+		return g.screen.decide(ctx, g, seat)
+	}
+	
+	for {
+		choice, err := g.playerAction(ctx, seat)
+		if err != nil && err != io.EOF {
+			fmt.Fprintln(g.out)
+			return decision{}, err
+		}
+		
+		action, invalid := parseChoice(choice)
+		switch {
+		case invalid == nil && action == raise:
+			return g.playerBet(ctx, seat)
+		case invalid == nil:
+			return decision{action: action}, nil
+		case err == io.EOF:
+			// Nobody is left at the keyboard to ask again
+			fmt.Fprintln(g.out, "\nNo more input, you fold.")
+			return decision{action: fold}, nil
+		}
+		fmt.Fprintf(g.out, "%v. Try again.\n", invalid)
+	}
+}
+
+// choiceError is an answer that isn't on the action menu
+type choiceError struct {
+	choice string
+}
+
+func (e *choiceError) Error() string {
+	return fmt.Sprintf("%q isn't one of the choices", e.choice)
+}
+
+// parseChoice reads an answer to the action menu
+func parseChoice(choice string) (actionKind, error) {
+	switch choice {
+	case "1": // Bet/Raise
+		return raise, nil
+	case "2": // Call
+		return call, nil
+	case "3": // Fold
+		return fold, nil
+	}
+	return 0, &choiceError{choice}
+}
+
+func (c consolePlayer) discard(g *game, seat int) []int {
+	positions, _ := c.discardWithin(context.Background(), g, seat)
+	return positions
+}
+
+func (consolePlayer) discardWithin(ctx context.Context, g *game, seat int) ([]int, error) {
+	positions, err := g.playerDiscards(ctx)
+	if err != nil {
+		fmt.Fprintln(g.out)
+	}
+	return positions, err
+}
+
+func (consolePlayer) straddle(g *game, seat int) bool {
+	fmt.Fprintf(g.out, "You're after the big blind. Straddle for %d chips? (y/N): ", 2*g.bigBlind)
+	
+	answer, _ := consoleInput().readLine(context.Background())
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
+// playHand plays one hand from the blinds to the chip counts at the end
+func playHand(game *game) {
+	fmt.Fprintln(game.out, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintf(game.out, "Starting new hand... (Dealer: %s)\n", game.players[game.dealer].name)
+	
+	// Play each phase in turn until the hand is paid out. The phases only
+	// move on as phaseTransitions allows, so a failure here is a bug.
+	for next := blindsPhase; ; next = game.step() {
+		if err := game.advance(next); err != nil {
+			panic(err)
+		}
+		game.audit("the " + game.phase.String())
+		if game.phase == betweenHands {
+			return
+		}
+	}
+}
+
+// Tools run as "poker <name> [flags]" instead of a game
+var subcommands = map[string]func(args []string) error{
+	"icm":      runICM,
+	"pushfold": runPushFold,
+	"serve":    runServe,
+	"connect":  runConnect,
+	"api":      runAPI,
+	"verify":   runVerify,
+	"mental":   runMental,
+	"coach":    runCoach,
+	"stats":    runStats,
+	"solve":    runSolve,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(2)
+			}
+			return
+		}
+	}
+
+	jokers := flag.Int("jokers", 0, "number of jokers to add to the deck")
+	deucesWild := flag.Bool("deuces-wild", false, "play every Two as a wild card")
+	bug := flag.Bool("bug", false, "jokers only count as an Ace or to complete a straight or flush")
+	limit := flag.String("limit", "no-limit", "betting structure: no-limit, pot-limit or fixed-limit")
+	smallBet := flag.Int("small-bet", 50, "fixed-limit bet size before the draw")
+	bigBet := flag.Int("big-bet", 100, "fixed-limit bet size after the draw")
+	raiseCap := flag.Int("raise-cap", 4, "fixed-limit bets and raises allowed per round (0 for no cap)")
+	opponents := flag.Int("opponents", 1, "number of computer opponents (1-7)")
+	ante := flag.Int("ante", 0, "ante posted by every player each hand")
+	bigBlindAnte := flag.Bool("bb-ante", false, "the big blind posts the ante for the whole table")
+	straddles := flag.Bool("straddle", false, "let the player after the big blind straddle (3 or more players)")
+	deadBlinds := flag.String("dead-blinds", "wait", "after sitting out: wait for the big blind, post missed blinds, or none")
+	tourney := flag.Bool("tournament", false, "play a freezeout tournament with rising blinds")
+	stack := flag.Int("stack", 1500, "tournament starting stack")
+	schedule := flag.String("schedule", "", "tournament blind levels, such as 25/50,50/100,100/200/25")
+	handsPerLevel := flag.Int("hands-per-level", 10, "hands played at each tournament blind level")
+	levelMinutes := flag.Int("level-minutes", 0, "minutes per tournament blind level, instead of counting hands")
+	buyIn := flag.Int("buy-in", 100, "tournament buy-in, which makes up the prize pool")
+	payouts := flag.String("payouts", "", "percentage of the prize pool paid to each place, such as 50,30,20")
+	tables := flag.Int("tables", 1, "tournament tables at the start, balanced and broken as players bust")
+	seats := flag.Int("seats", 8, "most players at a tournament table")
+	watchAll := flag.Bool("watch-all", false, "show the play at every tournament table, not just yours")
+	timeout := flag.Duration("timeout", 0, "time to act before you check or fold automatically, such as 30s (0 for no clock)")
+	timeBank := flag.Duration("time-bank", 0, "extra time to act, used up over the whole game")
+	spectating := flag.Bool("spectate", false, "watch the computers play without a seat yourself")
+	fair := flag.Bool("fair", false, "commit to each shuffle before the deal and reveal its seed after, for poker verify")
+	advisor := flag.Bool("advisor", false, "show the pot odds, your outs and your equity before each decision")
+	coaching := flag.Bool("coach", false, "go over your decisions after each hand, and sum them up at the end")
+	saveSession := flag.String("save-session", "", "save your decisions to a file, for poker coach to go over later")
+	profileName := flag.String("profile", "", "play as a saved profile, keeping your bankroll and stats from run to run")
+	profiles := flag.String("profiles", defaultProfilePath(), "file the player profiles are kept in")
+	solved := flag.String("strategy", "", "play the computers with a strategy written by poker solve -game draw")
+	fullScreen := flag.Bool("tui", false, "draw the table full screen, with the cards as glyphs and buttons to act")
+	seed := flag.Int64("seed", 0, "seed the deal and the computers' play, to replay a game exactly (0 for a new game each time)")
+	flag.Parse()
+	
+	structure, err := parseBettingStructure(*limit)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	policy, err := parseDeadBlindPolicy(*deadBlinds)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if err := checkSeats(*seats); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	maxOpponents := 7
+	if *tourney && *tables > 1 {
+		maxOpponents = *tables**seats - 1
+	}
+	if *opponents < 1 || *opponents > maxOpponents {
+		fmt.Printf("opponents must be between 1 and %d\n", maxOpponents)
+		os.Exit(2)
+	}
+	if *tables > 1 && *opponents+1 < 2**tables {
+		fmt.Println("every tournament table needs at least two players")
+		os.Exit(2)
+	}
+	if *fullScreen && *tourney && *tables > 1 {
+		fmt.Println("the full-screen table shows one table, so it can't be used with more than one tournament table")
+		os.Exit(2)
+	}
+	
 	fmt.Println("=== Welcome to Simple Poker! ===")
-	fmt.Println("You start with 1000 chips. Good luck!")
-	fmt.Println("WARNING: Folding means you lose any chips you've already bet (including blinds)!")
+	if *spectating {
+		fmt.Println("You're watching. Hole cards stay hidden until the showdown.")
+	} else if *profileName == "" {
+		fmt.Println("You start with 1000 chips. Good luck!")
+		fmt.Println("WARNING: Folding means you lose any chips you've already bet (including blinds)!")
+	}
 	
 	game := newGame()
-	scanner := bufio.NewScanner(os.Stdin)
-	
-	for !game.isGameOver() {
-		fmt.Println("\n" + strings.Repeat("=", 50))
-		fmt.Printf("Starting new hand... (Dealer: %s)\n", game.players[game.dealer].name)
-		
-		// Post blinds first
-		game.postBlinds()
-		
-		// Deal new hands
-		game.dealHands()
-		game.showPlayerHand()
-		
-		// Player's turn
-		action := game.playerAction()
+	game.wilds = wildRules{jokers: *jokers, deucesWild: *deucesWild, bug: *bug}
+	game.betting = bettingRules{structure: structure, smallBet: *smallBet, bigBet: *bigBet, raiseCap: *raiseCap}
+	game.addOpponents(*opponents)
+	if *spectating {
+		game.spectate()
+	}
+	if *solved != "" {
+		ss, err := loadStrategy(*solved)
+		if err == nil && ss.Game != "draw" {
+			err = fmt.Errorf("%s solves %s, but only a draw strategy can play this game", *solved, ss.Game)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		for i := range game.players {
+			if !game.isHuman(i) {
+				game.players[i].strategy = newSolvedBot(ss)
+			}
+		}
+		fmt.Println("The computers play the solved strategy heads-up at fixed-limit, and their usual game otherwise.")
+	}
+	game.ante = *ante
+	game.bigBlindAnte = *bigBlindAnte
+	game.straddles = *straddles
+	game.deadBlinds = policy
+	game.timeout = *timeout
+	game.timeBank = *timeBank
+	game.advisor = *advisor
+	if *profileName != "" && !*spectating {
+		store, err := loadProfiles(*profiles)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		you := store.get(*profileName)
+		you.Sessions++
+		if you.Bankroll <= 0 {
+			fmt.Printf("%s's bankroll is empty, so it's topped back up to %d chips.\n", you.Name, startingBankroll)
+			you.Bankroll = startingBankroll
+			you.Rebuys++
+		}
+		if err := store.save(); err != nil {
+			fmt.Printf("Couldn't save the player profiles: %v\n", err)
+		}
 		
-		switch action {
-		case "1": // Bet/Raise
-			game.playerBet()
-		case "2": // Call
-			callAmount := game.players[1].bet - game.players[0].bet
-			if callAmount > game.players[0].chips {
-				callAmount = game.players[0].chips
+		game.stats = newStatsKeeper(store)
+		game.stats.players[game.players[0].name] = you
+		if *tourney {
+			game.stats.bankroll = false // Tournament chips aren't the bankroll
+			fmt.Printf("Playing as %s. Good luck!\n", you.Name)
+		} else {
+			game.players[0].chips = you.Bankroll
+			fmt.Printf("Playing as %s. You sit down with your bankroll of %d chips. Good luck!\n", you.Name, you.Bankroll)
+		}
+	}
+	if *coaching || *saveSession != "" {
+		var save io.Writer
+		if *saveSession != "" {
+			f, err := os.Create(*saveSession)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(2)
 			}
-			game.players[0].chips -= callAmount
-			game.players[0].bet += callAmount
-			game.pot += callAmount
-			fmt.Printf("You call with %d additional chips. Total bet: %d, Pot: %d\n", 
-				callAmount, game.players[0].bet, game.pot)
-		case "3": // Fold
-			fmt.Printf("You fold! You lose %d chips already bet.\n", game.players[0].bet)
-			game.players[0].folded = true
-		default:
-			fmt.Printf("Invalid choice, you fold! You lose %d chips already bet.\n", game.players[0].bet)
-			game.players[0].folded = true
+			defer f.Close()
+			save = f
+		}
+		game.coach = newCoach(*coaching, save)
+		defer game.coach.summary(os.Stdout)
+	}
+	if *seed != 0 {
+		game.rng = rand.New(rand.NewSource(*seed))
+		if game.coach != nil {
+			game.coach.rng = rand.New(rand.NewSource(*seed))
+		}
+	}
+	if *fair {
+		game.fair = newFairDealer()
+	}
+	if *fullScreen {
+		screen, err := startTUI(game)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		defer screen.stop()
+		game.screen = screen
+		game.out = screen
+	}
+	fmt.Fprintf(game.out, "Wild cards: %s\n", game.wilds)
+	fmt.Fprintf(game.out, "Betting: %s\n", game.betting.structure)
+	input := consoleInput()
+	
+	var t *tournament
+	isOver := game.isGameOver
+	if *tourney {
+		levels := defaultSchedule()
+		if *schedule != "" {
+			levels, err = parseSchedule(*schedule)
+		}
+		prizes := defaultPayouts(len(game.players))
+		if err == nil && *payouts != "" {
+			prizes, err = parsePayouts(*payouts)
+		}
+		if err != nil {
+			game.screen.stop()
+			fmt.Println(err)
+			os.Exit(2)
 		}
 		
-		// Computer's turn (if player didn't fold)
-		if !game.players[0].folded {
-			game.computerAction()
+		seated := seatTables(game, *tables)
+		t = newTournament(seated, *stack, levels, *buyIn, prizes)
+		t.seats = *seats
+		t.handsPerLevel = *handsPerLevel
+		if *levelMinutes > 0 {
+			t.handsPerLevel = 0
+			t.levelDuration = time.Duration(*levelMinutes) * time.Minute
 		}
+		t.out = game.out
+		isOver = t.isOver
+		fmt.Fprintf(game.out, "Tournament: %d players, %d chips each, blinds start at %s\n", len(game.players), *stack, levels[0])
 		
-		// Determine winner
-		if game.players[0].folded {
-			fmt.Printf("You folded. Computer wins the pot of %d chips!\n", game.pot)
-			fmt.Printf("You lost %d chips from your bets/blinds.\n", game.players[0].bet)
-			game.players[1].chips += game.pot
-		} else if game.players[1].folded {
-			fmt.Printf("Computer folded. You win the pot of %d chips!\n", game.pot)
-			fmt.Printf("Computer lost %d chips from their bets/blinds.\n", game.players[1].bet)
-			game.players[0].chips += game.pot
-		} else {
-			game.showdown()
+		if *tables > 1 {
+			for _, table := range seated {
+				fmt.Printf("%s: %s\n", table.name, table.chipCounts())
+			}
+			t.runMultiTable(input, *watchAll || *spectating)
+			t.showStandings()
+			if place := t.placeOf(game.players[0].name); place > 0 && !*spectating {
+				fmt.Printf("You finished %s.\n", ordinal(place))
+			}
+			return
+		}
+	}
+	
+	for !isOver() {
+		if t != nil {
+			t.startHand()
 		}
 		
-		// Show chip counts after hand
-		fmt.Printf("\nChip counts after hand - You: %d, Computer: %d\n", 
-			game.players[0].chips, game.players[1].chips)
+		playHand(game)
+		if t != nil {
+			t.recordEliminations()
+		}
 		
-		// Ask if player wants to continue
-		if !game.isGameOver() {
-			fmt.Print("\nPress Enter to continue to next hand (or type 'quit' to exit): ")
-			scanner.Scan()
-			input := scanner.Text()
-			if input == "quit" {
+		// Ask if player wants to continue, unless you're out of the tournament and it plays itself out
+		if !isOver() && *spectating {
+			fmt.Fprint(game.out, "\nPress Enter to watch the next hand (or type 'quit' to exit): ")
+			if line, _ := input.readLine(context.Background()); line == "quit" {
+				break
+			}
+		} else if !isOver() && game.players[0].chips > 0 {
+			fmt.Fprint(game.out, "\nPress Enter to continue to next hand (or type 'sit' to sit out, 'back' to return, 'quit' to exit): ")
+			line, _ := input.readLine(context.Background())
+			if line == "quit" {
 				break
 			}
+			switch {
+			case t != nil:
+				// Tournament players can't sit out
+			case line == "sit":
+				if game.playersWithChips() < 3 {
+					fmt.Fprintln(game.out, "You can't sit out heads-up.")
+				} else {
+					game.players[0].sittingOut = true
+				}
+			case line == "back":
+				game.players[0].sittingOut = false
+			}
 		}
 		
 		// Reset for next round
@@ -87,14 +388,33 @@ func main() {
 	}
 	
 	// Game over
+	game.screen.stop()
+	if t != nil {
+		t.showStandings()
+		if place := t.placeOf(game.players[0].name); place > 0 && !*spectating {
+			fmt.Printf("You finished %s.\n", ordinal(place))
+		}
+		return
+	}
+	
 	fmt.Println("\n=== GAME OVER ===")
-	if game.players[0].chips > game.players[1].chips {
+	leader, tied := 0, false
+	for i, p := range game.players[1:] {
+		if p.chips > game.players[leader].chips {
+			leader, tied = i+1, false
+		} else if p.chips == game.players[leader].chips {
+			tied = true
+		}
+	}
+	if tied {
+		fmt.Println("It's a tie!")
+	} else if *spectating {
+		fmt.Printf("%s wins overall!\n", game.players[leader].name)
+	} else if leader == 0 {
 		fmt.Println("Congratulations! You won overall!")
-	} else if game.players[0].chips < game.players[1].chips {
-		fmt.Println("Computer wins overall! Better luck next time!")
 	} else {
-		fmt.Println("It's a tie!")
+		fmt.Printf("%s wins overall! Better luck next time!\n", game.players[leader].name)
 	}
 	
-	fmt.Printf("Final scores - You: %d, Computer: %d\n", game.players[0].chips, game.players[1].chips)
+	fmt.Printf("Final scores - %s\n", game.chipCounts())
 }
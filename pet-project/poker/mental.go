@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Mental poker deals without a dealer. Every player locks cards with their own
+// keys using SRA, a commutative cipher (a card m locked with key e is m^e mod p),
+// so locks can be put on and taken off in any order.
+//
+// The deck is shuffled by each player in turn: they lock all 52 cards with one
+// key and put them in an order only they know. Then each in turn takes that
+// lock off again and puts on a different lock for every card. A card is dealt
+// to a player when everyone else hands over their key for it, and shown at the
+// showdown when its owner hands over theirs. Nobody learns a card without
+// every other player's key for it, so no player, and not whoever relays the
+// messages, can see a card that isn't theirs.
+//
+// When the hand is over everyone hands over every key, and each player replays
+// the whole deal to check that nobody changed, copied or swapped a card.
+
+// sraPrime is the 1024-bit safe prime of RFC 2409 group 2. A hand's keys are
+// all revealed once it is over, so they only need to hold out for minutes, and
+// a smaller prime keeps the hundreds of locks and unlocks in a hand quick.
+// Every card code is a square mod p, and locking keeps it one, so a locked
+// card gives nothing away through being a square or not.
+var sraPrime, _ = new(big.Int).SetString(
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74"+
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437"+
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381FFFFFFFFFFFFFFFF", 16)
+
+// sraKey locks with e and unlocks with d, its inverse mod p-1
+type sraKey struct {
+	e, d *big.Int
+}
+
+// Exponents are taken mod p-1
+var sraOrder = new(big.Int).Sub(sraPrime, big.NewInt(1))
+
+func newSRAKey() sraKey {
+	for {
+		e, err := rand.Int(rand.Reader, sraOrder)
+		if err != nil {
+			panic(err)
+		}
+		if key, err := sraKeyFromUnlock(e); err == nil {
+			return key
+		}
+	}
+}
+
+// sraKeyFromUnlock rebuilds a key from the half that unlocks, as players hand
+// them over
+func sraKeyFromUnlock(d *big.Int) (sraKey, error) {
+	if d.Cmp(big.NewInt(1)) <= 0 || d.Cmp(sraOrder) >= 0 {
+		return sraKey{}, errors.New("key out of range")
+	}
+	e := new(big.Int).ModInverse(d, sraOrder)
+	if e == nil {
+		return sraKey{}, errors.New("key has no inverse")
+	}
+	return sraKey{e: e, d: d}, nil
+}
+
+func (k sraKey) lock(x *big.Int) *big.Int {
+	return new(big.Int).Exp(x, k.e, sraPrime)
+}
+
+func (k sraKey) unlock(x *big.Int) *big.Int {
+	return new(big.Int).Exp(x, k.d, sraPrime)
+}
+
+// cardCodes are the cards of newDeck as numbers: the square of a hash of the
+// card's name. Codes with anything in common, such as 4 and 16 = 4^2, would
+// keep it through every lock and give the cards away.
+var cardCodes, codeCards = func() ([]*big.Int, map[string]string) {
+	codes := []*big.Int{}
+	cards := make(map[string]string)
+	for _, c := range newDeck() {
+		var stretched []byte
+		for block := byte(0); len(stretched) <= len(sraPrime.Bytes()); block++ {
+			sum := sha256.Sum256(append([]byte(c), block))
+			stretched = append(stretched, sum[:]...)
+		}
+		code := new(big.Int).SetBytes(stretched)
+		code.Exp(code.Mod(code, sraPrime), big.NewInt(2), sraPrime)
+		codes = append(codes, code)
+		cards[code.Text(16)] = c
+	}
+	return codes, cards
+}()
+
+// The card a fully unlocked code stands for
+func decodeCard(x *big.Int) (string, bool) {
+	c, ok := codeCards[x.Text(16)]
+	return c, ok
+}
+
+// shufflePass locks every card with one key and shuffles them
+func shufflePass(in []*big.Int, key sraKey) []*big.Int {
+	out := lockAll(in, key)
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			panic(err)
+		}
+		out[i], out[j.Int64()] = out[j.Int64()], out[i]
+	}
+	return out
+}
+
+// lockPass swaps the shuffling lock for a lock on each card, in one step
+func lockPass(in []*big.Int, shuffleKey sraKey, cardKeys []sraKey) []*big.Int {
+	out := make([]*big.Int, len(in))
+	for i, x := range in {
+		swap := new(big.Int).Mul(shuffleKey.d, cardKeys[i].e)
+		out[i] = new(big.Int).Exp(x, swap.Mod(swap, sraOrder), sraPrime)
+	}
+	return out
+}
+
+// openCard takes every player's lock off a card
+func openCard(x *big.Int, keys []sraKey) (string, error) {
+	for _, k := range keys {
+		x = k.unlock(x)
+	}
+	if c, ok := decodeCard(x); ok {
+		return c, nil
+	}
+	return "", errors.New("the keys don't open it to a card")
+}
+
+// dealRecord is everything a hand's deal was made of. Once each player has
+// handed over every key it can be checked from start to finish.
+type dealRecord struct {
+	shuffles [][]*big.Int // each player's deck after the shuffling pass
+	locks    [][]*big.Int // each player's deck after the locking pass
+}
+
+// The deck cards are dealt from, with every player's lock on each card
+func (r dealRecord) deck() []*big.Int {
+	return r.locks[len(r.locks)-1]
+}
+
+// check replays the deal with every player's keys, returning the deck in the
+// order it was dealt, or the first player found cheating
+func (r dealRecord) check(shuffleKeys []sraKey, cardKeys [][]sraKey) ([]string, error) {
+	in := cardCodes
+	for p, out := range r.shuffles {
+		if !samePile(lockAll(in, shuffleKeys[p]), out) {
+			return nil, fmt.Errorf("player %d's shuffle isn't the cards they were given", p+1)
+		}
+		in = out
+	}
+	for p, out := range r.locks {
+		want := lockPass(in, shuffleKeys[p], cardKeys[p])
+		for i := range want {
+			if want[i].Cmp(out[i]) != 0 {
+				return nil, fmt.Errorf("player %d changed card %d while locking it", p+1, i+1)
+			}
+		}
+		in = out
+	}
+
+	dealt := make([]string, len(in))
+	for i, x := range in {
+		keys := make([]sraKey, len(cardKeys))
+		for p := range cardKeys {
+			keys[p] = cardKeys[p][i]
+		}
+		c, err := openCard(x, keys)
+		if err != nil {
+			return nil, fmt.Errorf("card %d: %v", i+1, err)
+		}
+		dealt[i] = c
+	}
+	return dealt, nil
+}
+
+func lockAll(in []*big.Int, key sraKey) []*big.Int {
+	out := make([]*big.Int, len(in))
+	for i, x := range in {
+		out[i] = key.lock(x)
+	}
+	return out
+}
+
+// Whether two piles hold the same numbers, in any order
+func samePile(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[string]int)
+	for _, x := range a {
+		count[x.Text(16)]++
+	}
+	for _, x := range b {
+		count[x.Text(16)]--
+		if count[x.Text(16)] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestSRAPrimeIsSafe(t *testing.T) {
+	q := new(big.Int).Rsh(sraPrime, 1)
+	if sraPrime.BitLen() != 1024 || !sraPrime.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+		t.Errorf("Expected p and (p-1)/2 to both be prime")
+	}
+}
+
+func TestSRALocksCommute(t *testing.T) {
+	a, b := newSRAKey(), newSRAKey()
+	card := cardCodes[7]
+	locked := b.lock(a.lock(card))
+	if got := a.unlock(b.unlock(locked)); got.Cmp(card) != 0 {
+		t.Fatalf("Expected the locks to come off in either order")
+	}
+	if c, _ := decodeCard(card); c != newDeck()[7] {
+		t.Errorf("Expected code 7 to be %v, but got %v", newDeck()[7], c)
+	}
+}
+
+// jointShuffle plays out a joint shuffle between players who all follow the protocol
+func jointShuffle(players int) (dealRecord, []sraKey, [][]sraKey) {
+	var record dealRecord
+	shuffleKeys := make([]sraKey, players)
+	cardKeys := make([][]sraKey, players)
+	in := cardCodes
+	for p := range shuffleKeys {
+		shuffleKeys[p] = newSRAKey()
+		in = shufflePass(in, shuffleKeys[p])
+		record.shuffles = append(record.shuffles, in)
+	}
+	for p := range cardKeys {
+		for range cardCodes {
+			cardKeys[p] = append(cardKeys[p], newSRAKey())
+		}
+		in = lockPass(in, shuffleKeys[p], cardKeys[p])
+		record.locks = append(record.locks, in)
+	}
+	return record, shuffleKeys, cardKeys
+}
+
+func TestDealRecordCatchesCheats(t *testing.T) {
+	record, shuffleKeys, cardKeys := jointShuffle(2)
+	dealt, err := record.check(shuffleKeys, cardKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, c := range dealt {
+		seen[c] = true
+	}
+	if len(seen) != 52 {
+		t.Errorf("Expected 52 different cards, but got %d", len(seen))
+	}
+
+	// The second player copies a card over another while shuffling
+	record.shuffles[1] = append([]*big.Int{}, record.shuffles[1]...)
+	record.shuffles[1][0] = record.shuffles[1][1]
+	if _, err := record.check(shuffleKeys, cardKeys); err == nil || !strings.Contains(err.Error(), "player 2") {
+		t.Errorf("Expected player 2's shuffle to be caught, but got %v", err)
+	}
+}
+
+func TestMentalGameOverTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go runRelay(ln, 2, 1000)
+
+	outs := make([]bytes.Buffer, 2)
+	errs := make(chan error, 2)
+	for i, name := range []string{"ann", "ben"} {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		go func(conn net.Conn, name string, out *bytes.Buffer) {
+			errs <- playMental(conn, name, simpleBot{}, out, 2)
+		}(conn, name, &outs[i])
+	}
+	for range outs {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := range outs {
+		if checked := strings.Count(outs[i].String(), "The deal checks out"); checked != 2 {
+			t.Errorf("Expected both hands to check out for player %d, but %d did:\n%s", i+1, checked, outs[i].String())
+		}
+	}
+	// Both players saw the same game, chip counts and all
+	last := func(out string) string {
+		return out[strings.LastIndex(out, "Chip counts after hand"):]
+	}
+	if a, b := last(outs[0].String()), last(outs[1].String()); a != b {
+		t.Errorf("Expected the players to agree on the chips, but got %q and %q", a, b)
+	}
+}
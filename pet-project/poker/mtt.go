@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// Fewest seats at a tournament table; the most is maxSeats, as many as a deck
+// deals five-card draw to with cards left for the draw
+const minSeats = 2
+
+// checkSeats refuses a tournament table size the game can't be played at
+func checkSeats(seats int) error {
+	if seats < minSeats || seats > maxSeats {
+		return fmt.Errorf("seats must be between %d and %d", minSeats, maxSeats)
+	}
+	return nil
+}
+
+// seatTables spreads the base game's players at random over a number of tables
+// that share its rules. A single table keeps its seating. A seeded game seeds
+// each table from its own generator, since the tables play at the same time.
+func seatTables(base *game, count int) []*game {
+	if count <= 1 {
+		return []*game{base}
+	}
+
+	entrants := make([]player, len(base.players))
+	copy(entrants, base.players)
+	for i := len(entrants) - 1; i > 0; i-- {
+		j := base.intn(i + 1)
+		entrants[i], entrants[j] = entrants[j], entrants[i]
+	}
+
+	tables := make([]*game, count)
+	for i := range tables {
+		table := *base
+		table.name = fmt.Sprintf("Table %d", i+1)
+		table.players = nil
+		if base.rng != nil {
+			table.rng = rand.New(rand.NewSource(base.rng.Int63()))
+		}
+		if base.fair != nil {
+			// Each table commits to its own run of shuffles
+			table.fair = newFairDealer()
+		}
+		tables[i] = &table
+	}
+	for i, p := range entrants {
+		table := tables[i%count]
+		table.players = append(table.players, p)
+	}
+	for _, table := range tables {
+		table.dealer = table.intn(len(table.players))
+	}
+	return tables
+}
+
+// Take a player out of their seat, keeping the dealer button where it was
+func (g *game) removePlayer(seat int) player {
+	p := g.players[seat]
+	g.players = append(g.players[:seat], g.players[seat+1:]...)
+	if seat < g.dealer {
+		g.dealer--
+	}
+	if g.dealer >= len(g.players) {
+		g.dealer = 0
+	}
+	g.bigBlindSeat = -1
+	return p
+}
+
+// Sit a player down in a random empty seat
+func (g *game) addPlayer(p player) {
+	seat := g.intn(len(g.players) + 1)
+	g.players = append(g.players, player{})
+	copy(g.players[seat+1:], g.players[seat:])
+	g.players[seat] = p
+	if seat <= g.dealer && len(g.players) > 1 {
+		g.dealer++
+	}
+	g.bigBlindSeat = -1
+}
+
+// Smallest and largest tables by players left
+func (t *tournament) shortestAndLongest() (int, int) {
+	shortest, longest := 0, 0
+	for i, table := range t.tables {
+		if len(table.players) < len(t.tables[shortest].players) {
+			shortest = i
+		}
+		if len(table.players) > len(t.tables[longest].players) {
+			longest = i
+		}
+	}
+	return shortest, longest
+}
+
+// balanceTables clears out busted players, breaks a table whenever the rest
+// have room for its players, then moves players from the fullest table to the
+// shortest until no table has two more players than another
+func (t *tournament) balanceTables() {
+	for _, table := range t.tables {
+		for seat := len(table.players) - 1; seat >= 0; seat-- {
+			if table.players[seat].chips == 0 {
+				table.removePlayer(seat)
+			}
+		}
+	}
+
+	for len(t.tables) > 1 && t.playersLeft() <= (len(t.tables)-1)*t.seats {
+		shortest, _ := t.shortestAndLongest()
+		broken := t.tables[shortest]
+		t.tables = append(t.tables[:shortest], t.tables[shortest+1:]...)
+		fmt.Fprintf(t.out, "\n*** %s breaks ***\n", broken.name)
+
+		for _, p := range broken.players {
+			next, _ := t.shortestAndLongest()
+			t.tables[next].addPlayer(p)
+			fmt.Fprintf(t.out, "%s moves to %s.\n", p.name, t.tables[next].name)
+		}
+	}
+
+	for {
+		shortest, longest := t.shortestAndLongest()
+		if len(t.tables[longest].players)-len(t.tables[shortest].players) <= 1 {
+			break
+		}
+		from := t.tables[longest]
+		p := from.removePlayer(from.intn(len(from.players)))
+		t.tables[shortest].addPlayer(p)
+		fmt.Fprintf(t.out, "%s moves from %s to %s to balance the tables.\n", p.name, from.name, t.tables[shortest].name)
+	}
+
+	if len(t.tables) == 1 && !t.finalTable {
+		t.finalTable = true
+		t.tables[0].name = "Final Table"
+		fmt.Fprintf(t.out, "\n*** Final table: %s ***\n", t.tables[0].chipCounts())
+	}
+}
+
+// lineWriter prefixes each whole line with the table name and writes it
+// under a shared lock, so concurrent tables don't mix their output
+type lineWriter struct {
+	mu     *sync.Mutex
+	prefix string
+	out    io.Writer
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "[%s] %s\n", w.prefix, w.buf[:i])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Show the human's table in full on the tournament's output; the others are
+// shown prefixed when watching every table, or not at all
+func (t *tournament) routeOutput(watchAll bool, mu *sync.Mutex) {
+	for _, table := range t.tables {
+		switch {
+		case table.humanSeat() >= 0:
+			table.out = t.out
+		case watchAll:
+			table.out = &lineWriter{mu: mu, prefix: table.name, out: t.out}
+		default:
+			table.out = io.Discard
+		}
+	}
+}
+
+// runMultiTable plays every table a hand at a time, all at once, then records
+// eliminations and balances the tables before the next hand
+func (t *tournament) runMultiTable(input *lineSource, watchAll bool) {
+	var mu sync.Mutex
+	round := 0
+	for !t.isOver() {
+		t.routeOutput(watchAll, &mu)
+		t.startHand()
+		round++
+
+		var wg sync.WaitGroup
+		for _, table := range t.tables {
+			if table.playersWithChips() < 2 {
+				continue
+			}
+			wg.Add(1)
+			go func(g *game) {
+				defer wg.Done()
+				playHand(g)
+				g.resetRound()
+			}(table)
+		}
+		wg.Wait()
+
+		t.recordEliminations()
+		if t.isOver() {
+			break
+		}
+		t.balanceTables()
+		fmt.Fprintf(t.out, "\nAfter hand %d: %d players left on %d tables\n", round, t.playersLeft(), len(t.tables))
+
+		if t.humanTable() != nil {
+			fmt.Fprint(t.out, "\nPress Enter to continue to next hand (or type 'quit' to exit): ")
+			if line, _ := input.readLine(context.Background()); line == "quit" {
+				return
+			}
+		}
+	}
+}
+
+// Table the human is sitting at, or nil once they are out
+func (t *tournament) humanTable() *game {
+	for _, table := range t.tables {
+		if table.humanSeat() >= 0 {
+			return table
+		}
+	}
+	return nil
+}
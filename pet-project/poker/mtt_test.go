@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func newTestTournament(opponents, tables, seats int) *tournament {
+	g := newGame()
+	g.players[0].strategy = simpleBot{}
+	g.addOpponents(opponents)
+	tour := newTournament(seatTables(g, tables), 1500, defaultSchedule(), 100, []int{100})
+	tour.seats = seats
+	tour.out = io.Discard
+	for _, table := range tour.tables {
+		table.out = io.Discard
+	}
+	return tour
+}
+
+func TestSeatTablesSpreadsPlayers(t *testing.T) {
+	tour := newTestTournament(19, 3, 8)
+
+	seated := 0
+	for _, table := range tour.tables {
+		if len(table.players) < 6 || len(table.players) > 7 {
+			t.Errorf("Expected 6 or 7 players at %v, but got %v", table.name, len(table.players))
+		}
+		seated += len(table.players)
+	}
+	if seated != 20 {
+		t.Errorf("Expected 20 players seated, but got %v", seated)
+	}
+}
+
+func TestCheckSeats(t *testing.T) {
+	for _, seats := range []int{0, 1, 9, 20} {
+		if err := checkSeats(seats); err == nil {
+			t.Errorf("Expected %d seats to be refused", seats)
+		}
+	}
+	for _, seats := range []int{2, 6, 8} {
+		if err := checkSeats(seats); err != nil {
+			t.Errorf("Expected %d seats to be allowed, but got %v", seats, err)
+		}
+	}
+}
+
+func TestBalanceTablesBreaksShortTable(t *testing.T) {
+	tour := newTestTournament(15, 3, 6)
+	var out bytes.Buffer
+	tour.out = &out
+
+	// Bust players until 12 are left, which fit on two tables of 6
+	busted := 0
+	for _, table := range tour.tables {
+		for i := range table.players {
+			if busted < 4 {
+				table.players[i].chips = 0
+				busted++
+			}
+		}
+	}
+	tour.balanceTables()
+
+	if len(tour.tables) != 2 {
+		t.Fatalf("Expected a table to break, but got %v tables", len(tour.tables))
+	}
+	for _, table := range tour.tables {
+		if len(table.players) != 6 {
+			t.Errorf("Expected 6 players at %v, but got %v", table.name, len(table.players))
+		}
+	}
+	if !bytes.Contains(out.Bytes(), []byte("breaks ***")) || bytes.Count(out.Bytes(), []byte(" moves ")) < 2 {
+		t.Errorf("Expected the break and the moves announced on the tournament's output, but got %q", out.String())
+	}
+}
+
+func TestMultiTableRunsToOneWinner(t *testing.T) {
+	tour := newTestTournament(17, 3, 6)
+	tour.handsPerLevel = 2
+
+	// No human is playing, so the tables run without asking for input
+	tour.runMultiTable(nil, false)
+
+	total := 0
+	for _, table := range tour.tables {
+		for _, p := range table.players {
+			total += p.chips
+		}
+	}
+	if total != 18*1500 {
+		t.Errorf("Expected %v chips in play, but got %v", 18*1500, total)
+	}
+	if len(tour.finishes) != 18 || tour.placeOf(tour.finishes[17].name) != 1 {
+		t.Errorf("Expected 18 finishing places ending with a winner, but got %v", len(tour.finishes))
+	}
+}
+
+func TestSeededMultiTableIsRepeatable(t *testing.T) {
+	play := func() []finish {
+		g := newGame()
+		g.players[0].strategy = simpleBot{}
+		g.addOpponents(11)
+		g.rng = rand.New(rand.NewSource(42))
+		tour := newTournament(seatTables(g, 3), 1500, defaultSchedule(), 100, []int{100})
+		tour.seats = 6
+		tour.handsPerLevel = 2
+		tour.out = io.Discard
+		tour.runMultiTable(nil, false)
+		return tour.finishes
+	}
+
+	first, second := play(), play()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same seed to finish the same way, but got\n%v\n%v", first, second)
+	}
+}
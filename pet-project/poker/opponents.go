@@ -0,0 +1,221 @@
+package main
+
+import "math"
+
+// The adaptive bot plays the computer's hand-strength game, but keeps a model
+// of everyone it sits with and moves its chances to exploit them:
+//
+//	bluffs      how often a player who bet shows down High Card. Against a
+//	            bluffer it calls lighter, and against a rock it lays pairs down.
+//	fold rate   how often a player folds to a raise. It bluffs more at players
+//	            who fold, and bets its good hands bigger into those who don't.
+//	bet sizes   the sizes a player bets with strong and weak hands, once they
+//	            have been seen at showdown. A bet sized like their strong ones
+//	            is respected, and one sized like their bluffs is called.
+
+// What the model believes of a player it hasn't seen yet, and how many
+// observations that belief is worth
+const (
+	priorBluffs   = 0.2
+	priorFoldRate = 0.5
+	priorWeight   = 5
+)
+
+// tableWatcher is a strategy that learns from everyone's play, not just its own
+type tableWatcher interface {
+	watchAction(g *game, seat int, d decision) // before the action is applied
+	watchHandEnd(g *game, showdown bool)       // once the pot has been paid out
+}
+
+// Tell the seats that learn from the table about an action
+func (g *game) watchAction(seat int, d decision) {
+	for _, p := range g.players {
+		if w, ok := p.strategy.(tableWatcher); ok {
+			w.watchAction(g, seat, d)
+		}
+	}
+}
+
+// Tell the seats that learn from the table the hand is over
+func (g *game) watchHandEnd(showdown bool) {
+	for _, p := range g.players {
+		if w, ok := p.strategy.(tableWatcher); ok {
+			w.watchHandEnd(g, showdown)
+		}
+	}
+}
+
+// opponentModel is what the bot has learnt of one player
+type opponentModel struct {
+	facedRaise    int // times they had to call a raise
+	foldedToRaise int
+	shownBets     int       // showdowns after they bet or raised in the hand
+	shownBluffs   int       // those that were High Card
+	strongSizes   []float64 // raises over the pot, with Two Pair or better
+	weakSizes     []float64 // and with One Pair or worse
+}
+
+// Share of a player's bets that were bluffs, leaning on the prior until
+// there have been a few showdowns
+func (m *opponentModel) bluffs() float64 {
+	return (float64(m.shownBluffs) + priorBluffs*priorWeight) / float64(m.shownBets+priorWeight)
+}
+
+func (m *opponentModel) foldRate() float64 {
+	return (float64(m.foldedToRaise) + priorFoldRate*priorWeight) / float64(m.facedRaise+priorWeight)
+}
+
+// sizeTell reads a raise by its size: 1 when it is sized like the player's
+// strong hands, -1 like their weak ones, and 0 when there's no telling
+func (m *opponentModel) sizeTell(size float64) int {
+	if len(m.strongSizes) < 2 || len(m.weakSizes) < 2 {
+		return 0
+	}
+	strong, weak := mean(m.strongSizes), mean(m.weakSizes)
+	if math.Abs(strong-weak) < 0.15 {
+		return 0
+	}
+	if math.Abs(size-strong) < math.Abs(size-weak) {
+		return 1
+	}
+	return -1
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// adaptiveBot is the computer player that models its opponents
+type adaptiveBot struct {
+	models map[string]*opponentModel // by name, which stays with a player who changes tables
+
+	// The hand being played
+	aggressor      string // who made the last raise
+	aggressorRound string
+	aggressorSize  float64
+	bet            map[string]bool      // players who bet or raised
+	sizes          map[string][]float64 // the sizes of their raises
+}
+
+func newAdaptiveBot() *adaptiveBot {
+	return &adaptiveBot{models: make(map[string]*opponentModel)}
+}
+
+func (b *adaptiveBot) decide(g *game, seat int) decision {
+	return g.thresholdAction(seat, b.thresholds(g, seat))
+}
+
+func (b *adaptiveBot) discard(g *game, seat int) []int {
+	return g.computerDiscards(seat)
+}
+
+func (b *adaptiveBot) model(name string) *opponentModel {
+	m, ok := b.models[name]
+	if !ok {
+		m = &opponentModel{}
+		b.models[name] = m
+	}
+	return m
+}
+
+func (b *adaptiveBot) me(g *game, seat int) bool {
+	return g.players[seat].strategy == strategy(b)
+}
+
+// Whether a seat has a raise to call, not just the blinds or a check
+func (b *adaptiveBot) facingRaise(g *game, seat int) bool {
+	return g.toCall(seat) > 0 && b.aggressor != "" && b.aggressorRound == g.round
+}
+
+func (b *adaptiveBot) watchAction(g *game, seat int, d decision) {
+	name := g.players[seat].name
+	if !b.me(g, seat) && b.facingRaise(g, seat) {
+		m := b.model(name)
+		m.facedRaise++
+		if d.action == fold {
+			m.foldedToRaise++
+		}
+	}
+	if d.action != raise {
+		return
+	}
+	if b.bet == nil {
+		b.bet = make(map[string]bool)
+		b.sizes = make(map[string][]float64)
+	}
+	size := float64(d.amount-g.highestBet()) / float64(max(g.pot, 1))
+	b.aggressor, b.aggressorRound, b.aggressorSize = name, g.round, size
+	b.bet[name] = true
+	b.sizes[name] = append(b.sizes[name], size)
+}
+
+// watchHandEnd learns what the players who went to showdown were betting with
+func (b *adaptiveBot) watchHandEnd(g *game, showdown bool) {
+	for i, p := range g.players {
+		if b.me(g, i) || len(p.hand) == 0 {
+			continue
+		}
+		if !showdown || p.folded || !b.bet[p.name] {
+			continue
+		}
+		m := b.model(p.name)
+		m.shownBets++
+		if g.evaluate(p.hand).rank >= 3 {
+			m.strongSizes = append(m.strongSizes, b.sizes[p.name]...)
+		} else {
+			m.weakSizes = append(m.weakSizes, b.sizes[p.name]...)
+			if g.evaluate(p.hand).rank == 1 {
+				m.shownBluffs++
+			}
+		}
+	}
+	b.aggressor, b.aggressorRound = "", ""
+	b.bet, b.sizes = nil, nil
+}
+
+// thresholds moves the computer's fixed chances to suit the players in the hand
+func (b *adaptiveBot) thresholds(g *game, seat int) thresholds {
+	t := fixedThresholds
+
+	// A bluff has to get through everyone left, so it is sized to the
+	// stickiest of them, as are bets for value
+	folds := 1.0
+	for i, p := range g.players {
+		if i != seat && !p.folded {
+			folds = min(folds, b.model(p.name).foldRate())
+		}
+	}
+	t.bluff = clamp(t.bluff*folds/priorFoldRate, 0.05, 0.6)
+	t.bluffRaise = clamp(t.bluffRaise*folds/priorFoldRate, 0.2, 1)
+	if folds < priorFoldRate {
+		t.strongRaise += priorFoldRate - folds
+		t.sizing += 2 * (priorFoldRate - folds)
+	}
+
+	// Facing a raise, the raiser's bluffs and bet sizes say how light to call
+	if !b.facingRaise(g, seat) {
+		return t
+	}
+	m := b.model(b.aggressor)
+	bluffs := m.bluffs()
+	t.pairFold = clamp(t.pairFold*priorBluffs/bluffs, 0.05, 0.9)
+	t.catchBluff = max(0, bluffs-priorBluffs)
+	switch m.sizeTell(b.aggressorSize) {
+	case 1:
+		t.pairFold = min(0.9, t.pairFold*1.5)
+		t.catchBluff = 0
+	case -1:
+		t.pairFold /= 2
+		t.catchBluff += 0.1
+	}
+	t.pairRaise = min(t.pairRaise, 1-t.pairFold)
+	return t
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return max(lo, min(x, hi))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+// watchHand shows the bot in seat 1 a hand where You hold the given cards,
+// raise to youRaise and either fold to the bot's re-raise or go to showdown
+func watchHand(g *game, hand deck, youRaise int, foldToRaise bool) {
+	g.resetRound()
+	for i := range g.players {
+		g.players[i].chips = 1000
+	}
+	g.postBlinds()
+	g.dealHands()
+	g.players[0].hand = hand
+	play := func(seat int, d decision) {
+		g.watchAction(seat, d)
+		g.apply(seat, d)
+	}
+	play(0, decision{action: raise, amount: youRaise})
+	play(1, decision{action: raise, amount: 2 * youRaise})
+	if foldToRaise {
+		play(0, decision{action: fold})
+	} else {
+		play(0, decision{action: call})
+	}
+	g.watchHandEnd(!foldToRaise)
+}
+
+// facing sets up a hand where You have just raised to amount
+func facing(g *game, amount int) {
+	g.resetRound()
+	g.postBlinds()
+	g.dealHands()
+	d := decision{action: raise, amount: amount}
+	g.watchAction(0, d)
+	g.apply(0, d)
+}
+
+func TestAdaptiveBotExploitsWhatItSees(t *testing.T) {
+	junk := deck{"Two of Spades", "Four of Hearts", "Seven of Diamonds", "Nine of Clubs", "Jack of Hearts"}
+	trips := deck{"King of Spades", "King of Hearts", "King of Clubs", "Four of Diamonds", "Nine of Spades"}
+	newTable := func() (*game, *adaptiveBot) {
+		g := newGame()
+		g.out = io.Discard
+		bot := newAdaptiveBot()
+		g.players[1].strategy = bot
+		return g, bot
+	}
+
+	// Until it has seen anything, it plays the fixed game
+	g, bot := newTable()
+	facing(g, 200)
+	if got := bot.thresholds(g, 1); got != fixedThresholds {
+		t.Errorf("Expected an unknown opponent to get the fixed thresholds, but got %+v", got)
+	}
+
+	// Someone who keeps showing down High Card after raising gets called lighter
+	g, bot = newTable()
+	for i := 0; i < 10; i++ {
+		watchHand(g, junk, 200, false)
+	}
+	facing(g, 200)
+	got := bot.thresholds(g, 1)
+	if got.pairFold >= fixedThresholds.pairFold/2 || got.catchBluff < 0.3 {
+		t.Errorf("Expected to call a bluffer down lighter, but got %+v", got)
+	}
+
+	// Someone who folds to every raise gets bluffed more, and sticky players less
+	g, bot = newTable()
+	for i := 0; i < 10; i++ {
+		watchHand(g, junk, 100, true)
+	}
+	g.resetRound()
+	if got := bot.thresholds(g, 1); got.bluff <= fixedThresholds.bluff || got.bluffRaise <= fixedThresholds.bluffRaise {
+		t.Errorf("Expected to bluff more at a player who folds, but got %+v", got)
+	}
+	g, bot = newTable()
+	for i := 0; i < 10; i++ {
+		watchHand(g, trips, 100, false)
+	}
+	g.resetRound()
+	if got := bot.thresholds(g, 1); got.bluff >= fixedThresholds.bluff || got.sizing <= 1 {
+		t.Errorf("Expected to bluff less and bet bigger into a player who never folds, but got %+v", got)
+	}
+
+	// Someone who bets big with their good hands and small with their bluffs
+	g, bot = newTable()
+	for i := 0; i < 3; i++ {
+		watchHand(g, trips, 300, false)
+		watchHand(g, junk, 100, false)
+	}
+	facing(g, 300)
+	big := bot.thresholds(g, 1)
+	facing(g, 100)
+	small := bot.thresholds(g, 1)
+	if big.pairFold <= small.pairFold || big.catchBluff != 0 || small.catchBluff == 0 {
+		t.Errorf("Expected a big bet to be respected and a small one called, but got %+v and %+v", big, small)
+	}
+}
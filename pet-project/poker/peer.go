@@ -0,0 +1,688 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Peer-to-peer games are played by a few processes connected through a relay.
+// The relay only numbers the players as they arrive and passes every message
+// from each of them on to all of them, in one order. Each player runs the
+// same game on the same messages, so they all agree on every bet, and the
+// cards are dealt by mental poker (mental.go), so nobody has to trust the
+// relay, or anyone else, with them.
+//
+// Messages are signed with a key each player makes for the game, and
+// numbered, so the relay can't forge or replay them. It could still pose as
+// each player to the others from the start, so players should compare the
+// key fingerprints shown when the game begins.
+
+// Most players in a peer-to-peer game: five cards each and three more each at
+// the draw still fit in 52 cards
+const maxPeers = 6
+
+// hiddenCard stands for a card dealt to somebody else
+const hiddenCard = "Hidden card"
+
+// relayPeer is a player connected to the relay
+type relayPeer struct {
+	conn net.Conn
+	in   *bufio.Reader
+	name string
+	key  string
+}
+
+// runRelay waits for the players, tells each of them who is playing and in
+// which seat, then passes their messages on until they have all gone
+func runRelay(ln net.Listener, players int, stack int) error {
+	defer ln.Close()
+	var peers []*relayPeer
+	for len(peers) < players {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		p := &relayPeer{conn: conn, in: bufio.NewReader(conn)}
+		if err := p.greet(peers); err != nil {
+			fmt.Fprintf(conn, "ERROR %v\n", err)
+			conn.Close()
+			continue
+		}
+		peers = append(peers, p)
+	}
+
+	roster := make([]string, len(peers))
+	for i, p := range peers {
+		roster[i] = p.name + ":" + p.key
+	}
+	for seat, p := range peers {
+		fmt.Fprintf(p.conn, "ROSTER %d %d %s\n", stack, seat, strings.Join(roster, " "))
+	}
+
+	lines := make(chan string)
+	var wg sync.WaitGroup
+	for seat, p := range peers {
+		wg.Add(1)
+		go func(seat int, p *relayPeer) {
+			defer wg.Done()
+			for {
+				line, err := p.in.ReadString('\n')
+				if err != nil {
+					lines <- fmt.Sprintf("GONE %d", seat)
+					return
+				}
+				lines <- fmt.Sprintf("FROM %d %s", seat, strings.TrimSpace(line))
+			}
+		}(seat, p)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	for line := range lines {
+		for _, p := range peers {
+			fmt.Fprintln(p.conn, line)
+		}
+	}
+	for _, p := range peers {
+		p.conn.Close()
+	}
+	return nil
+}
+
+// greet reads a player's HELLO <name> <key>
+func (p *relayPeer) greet(seated []*relayPeer) error {
+	line, err := p.in.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 3 || fields[0] != "HELLO" {
+		return errors.New("say HELLO <name> <key> first")
+	}
+	if !validName.MatchString(fields[1]) {
+		return errors.New("names are 1-16 letters, digits, - or _")
+	}
+	for _, other := range seated {
+		if strings.EqualFold(other.name, fields[1]) {
+			return fmt.Errorf("%s is taken", fields[1])
+		}
+	}
+	p.name, p.key = fields[1], fields[2]
+	return nil
+}
+
+// mentalTable is this player's end of a peer-to-peer game: the messages to
+// and from the others, and the keys and positions of the hand being dealt
+type mentalTable struct {
+	seat    int
+	names   []string
+	keys    []ed25519.PublicKey
+	signing ed25519.PrivateKey
+	conn    net.Conn
+	in      *bufio.Reader
+	sent    int           // messages this player has sent
+	heard   []int         // messages heard from each player
+	pending []peerMessage // heard before they were wanted
+
+	record     dealRecord
+	shuffleKey sraKey
+	cardKeys   []sraKey     // this player's lock on each card
+	handedOver [][]*big.Int // the unlocking keys each player has handed over, by card
+	positions  [][]int      // where each seat's cards are in the deck
+	next       int          // the top of the deck
+}
+
+// peerMessage is a message from one of the players
+type peerMessage struct {
+	from int
+	kind string
+	args []string
+}
+
+// mentalAbort stops the game when it can't go on, such as when a player
+// leaves or cheats. playMental recovers it.
+type mentalAbort struct {
+	err error
+}
+
+func (t *mentalTable) fail(format string, args ...any) {
+	panic(mentalAbort{fmt.Errorf(format, args...)})
+}
+
+// send signs and numbers a message for the others
+func (t *mentalTable) send(kind string, args ...string) {
+	body := strings.Join(append([]string{strconv.Itoa(t.sent), kind}, args...), " ")
+	sig := ed25519.Sign(t.signing, []byte(fmt.Sprintf("%d %s", t.seat, body)))
+	if _, err := fmt.Fprintf(t.conn, "%s %s\n", base64.StdEncoding.EncodeToString(sig), body); err != nil {
+		t.fail("lost the connection to the relay: %v", err)
+	}
+	t.sent++
+}
+
+// expect waits for the next message of a kind from a player
+func (t *mentalTable) expect(from int, kind string) []string {
+	for i, m := range t.pending {
+		if m.from == from && m.kind == kind {
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			return m.args
+		}
+	}
+	for {
+		m := t.hear()
+		if m.from == from && m.kind == kind {
+			return m.args
+		}
+		t.pending = append(t.pending, m)
+	}
+}
+
+// hear reads the next message from another player, checking it is theirs
+func (t *mentalTable) hear() peerMessage {
+	for {
+		line, err := t.in.ReadString('\n')
+		if err != nil {
+			t.fail("lost the connection to the relay")
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "GONE" {
+			seat, _ := strconv.Atoi(fields[1])
+			if seat >= 0 && seat < len(t.names) {
+				t.fail("%s left the game", t.names[seat])
+			}
+		}
+		if len(fields) < 5 || fields[0] != "FROM" {
+			t.fail("the relay sent %q", strings.TrimSpace(line))
+		}
+
+		from, err := strconv.Atoi(fields[1])
+		if err != nil || from < 0 || from >= len(t.names) {
+			t.fail("the relay sent a message from nobody")
+		}
+		sig, _ := base64.StdEncoding.DecodeString(fields[2])
+		body := strings.Join(fields[3:], " ")
+		if !ed25519.Verify(t.keys[from], []byte(fmt.Sprintf("%d %s", from, body)), sig) {
+			t.fail("a message said to be from %s isn't signed by them", t.names[from])
+		}
+		if seq, _ := strconv.Atoi(fields[3]); seq != t.heard[from] {
+			t.fail("a message from %s came out of order", t.names[from])
+		}
+		t.heard[from]++
+		if from != t.seat {
+			return peerMessage{from: from, kind: fields[4], args: fields[5:]}
+		}
+	}
+}
+
+// numbers reads a message of numbers in hex, as locked cards and keys are sent
+func (t *mentalTable) numbers(from int, kind string, count int) []*big.Int {
+	args := t.expect(from, kind)
+	if len(args) != count {
+		t.fail("%s sent %d numbers in %s, not %d", t.names[from], len(args), kind, count)
+	}
+	xs := make([]*big.Int, count)
+	for i, arg := range args {
+		x, ok := new(big.Int).SetString(arg, 16)
+		if !ok || x.Sign() <= 0 || x.Cmp(sraPrime) >= 0 {
+			t.fail("%s sent a bad number in %s", t.names[from], kind)
+		}
+		xs[i] = x
+	}
+	return xs
+}
+
+func hexNumbers(xs []*big.Int) []string {
+	out := make([]string, len(xs))
+	for i, x := range xs {
+		out[i] = x.Text(16)
+	}
+	return out
+}
+
+// shuffle runs both passes of the joint shuffle for a new hand, each player in
+// seat order
+func (t *mentalTable) shuffle() {
+	t.shuffleKey = newSRAKey()
+	t.cardKeys = make([]sraKey, len(cardCodes))
+	for i := range t.cardKeys {
+		t.cardKeys[i] = newSRAKey()
+	}
+	t.record = dealRecord{}
+	t.handedOver = make([][]*big.Int, len(t.names))
+	for p := range t.handedOver {
+		t.handedOver[p] = make([]*big.Int, len(cardCodes))
+	}
+	t.positions = make([][]int, len(t.names))
+	t.next = 0
+
+	in := cardCodes
+	for p := range t.names {
+		if p == t.seat {
+			in = shufflePass(in, t.shuffleKey)
+			t.send("SHUFFLE", hexNumbers(in)...)
+		} else {
+			in = t.numbers(p, "SHUFFLE", len(cardCodes))
+		}
+		t.record.shuffles = append(t.record.shuffles, in)
+	}
+	for p := range t.names {
+		if p == t.seat {
+			in = lockPass(in, t.shuffleKey, t.cardKeys)
+			t.send("LOCK", hexNumbers(in)...)
+		} else {
+			in = t.numbers(p, "LOCK", len(cardCodes))
+		}
+		t.record.locks = append(t.record.locks, in)
+	}
+}
+
+// Positions of the next cards off the top of the deck
+func (t *mentalTable) take(count int) []int {
+	if t.next+count > len(cardCodes) {
+		t.fail("the deck ran out")
+	}
+	positions := make([]int, count)
+	for i := range positions {
+		positions[i] = t.next + i
+	}
+	t.next += count
+	return positions
+}
+
+// handOut deals cards by position to seats. Every player hands over their
+// keys for the cards going to the others, then opens their own.
+func (t *mentalTable) handOut(deal [][]int) deck {
+	owner := make(map[int]int)
+	var keys []string
+	for seat, positions := range deal {
+		for _, pos := range positions {
+			owner[pos] = seat
+			if seat != t.seat {
+				keys = append(keys, fmt.Sprintf("%d:%s", pos, t.cardKeys[pos].d.Text(16)))
+			}
+		}
+	}
+	t.send("KEYS", keys...)
+
+	for p := range t.names {
+		if p != t.seat {
+			t.takeKeys(p, "KEYS", func(pos int) bool {
+				seat, dealt := owner[pos]
+				return dealt && seat != p
+			})
+		}
+	}
+
+	mine := deck{}
+	for _, pos := range deal[t.seat] {
+		mine = append(mine, t.open(pos))
+	}
+	return mine
+}
+
+// takeKeys reads a player's "position:key" pairs, for positions they may hand over
+func (t *mentalTable) takeKeys(from int, kind string, allowed func(pos int) bool) {
+	for _, arg := range t.expect(from, kind) {
+		posText, keyText, _ := strings.Cut(arg, ":")
+		pos, err := strconv.Atoi(posText)
+		d, ok := new(big.Int).SetString(keyText, 16)
+		if err != nil || !ok || !allowed(pos) {
+			t.fail("%s handed over a key they shouldn't have", t.names[from])
+		}
+		t.handedOver[from][pos] = d
+	}
+}
+
+// open takes every player's lock off the card at a position
+func (t *mentalTable) open(pos int) string {
+	keys := make([]sraKey, len(t.names))
+	for p := range t.names {
+		if p == t.seat {
+			keys[p] = t.cardKeys[pos]
+			continue
+		}
+		d := t.handedOver[p][pos]
+		if d == nil {
+			t.fail("%s didn't hand over their key for card %d", t.names[p], pos+1)
+		}
+		key, err := sraKeyFromUnlock(d)
+		if err != nil {
+			t.fail("%s handed over a bad key for card %d: %v", t.names[p], pos+1, err)
+		}
+		keys[p] = key
+	}
+	card, err := openCard(t.record.deck()[pos], keys)
+	if err != nil {
+		t.fail("card %d: %v, so someone handed over a wrong key", pos+1, err)
+	}
+	return card
+}
+
+// Hidden cards, for a hand dealt to somebody else
+func hiddenCards(count int) deck {
+	cards := make(deck, count)
+	for i := range cards {
+		cards[i] = hiddenCard
+	}
+	return cards
+}
+
+// dealHands shuffles and deals five cards to everyone in the hand
+func (t *mentalTable) dealHands(g *game) {
+	t.shuffle()
+	deal := make([][]int, len(g.players))
+	for seat, p := range g.players {
+		if !p.folded { // Sitting out or out of chips
+			deal[seat] = t.take(5)
+		}
+	}
+	mine := t.handOut(deal)
+
+	for seat := range g.players {
+		t.positions[seat] = deal[seat]
+		if seat == t.seat {
+			g.players[seat].hand = mine
+		} else {
+			g.players[seat].hand = hiddenCards(len(deal[seat]))
+		}
+	}
+}
+
+// draw deals a seat new cards for the ones they threw away at these positions
+func (t *mentalTable) draw(g *game, seat int, positions []int) deck {
+	deal := make([][]int, len(g.players))
+	deal[seat] = t.take(len(positions))
+	mine := t.handOut(deal)
+	for i, pos := range positions {
+		t.positions[seat][pos] = deal[seat][i]
+	}
+	if seat == t.seat {
+		return mine
+	}
+	return hiddenCards(len(positions))
+}
+
+// showHands has everyone still in the hand hand over the keys to their cards
+func (t *mentalTable) showHands(g *game) {
+	for seat, p := range g.players {
+		if p.folded {
+			continue
+		}
+		if seat == t.seat {
+			var keys []string
+			for _, pos := range t.positions[seat] {
+				keys = append(keys, fmt.Sprintf("%d:%s", pos, t.cardKeys[pos].d.Text(16)))
+			}
+			t.send("SHOW", keys...)
+			continue
+		}
+
+		t.takeKeys(seat, "SHOW", func(pos int) bool {
+			for _, held := range t.positions[seat] {
+				if pos == held {
+					return true
+				}
+			}
+			return false
+		})
+		hand := deck{}
+		for _, pos := range t.positions[seat] {
+			hand = append(hand, t.open(pos))
+		}
+		g.players[seat].hand = hand
+	}
+}
+
+// verify has everyone reveal every key once the hand is over, and replays the
+// deal to check nobody cheated
+func (t *mentalTable) verify(g *game) {
+	keys := []string{t.shuffleKey.d.Text(16)}
+	for _, k := range t.cardKeys {
+		keys = append(keys, k.d.Text(16))
+	}
+	t.send("REVEAL", keys...)
+
+	shuffleKeys := make([]sraKey, len(t.names))
+	cardKeys := make([][]sraKey, len(t.names))
+	for p := range t.names {
+		if p == t.seat {
+			shuffleKeys[p], cardKeys[p] = t.shuffleKey, t.cardKeys
+			continue
+		}
+		revealed := t.numbers(p, "REVEAL", len(cardCodes)+1)
+		for i, d := range revealed {
+			key, err := sraKeyFromUnlock(d)
+			if err != nil {
+				t.fail("%s revealed a bad key: %v", t.names[p], err)
+			}
+			if i == 0 {
+				shuffleKeys[p] = key
+			} else {
+				cardKeys[p] = append(cardKeys[p], key)
+			}
+		}
+		for pos, d := range t.handedOver[p] {
+			if d != nil && d.Cmp(cardKeys[p][pos].d) != 0 {
+				t.fail("%s revealed a different key for card %d than the one they handed over", t.names[p], pos+1)
+			}
+		}
+	}
+
+	dealt, err := t.record.check(shuffleKeys, cardKeys)
+	if err != nil {
+		t.fail("the deal doesn't check out: %v", err)
+	}
+	hand := g.players[t.seat].hand
+	for i, pos := range t.positions[t.seat] {
+		if i < len(hand) && dealt[pos] != hand[i] {
+			t.fail("the deal doesn't check out: you were dealt %s, but the deck says %s", hand[i], dealt[pos])
+		}
+	}
+	fmt.Fprintln(g.out, "The deal checks out: every card came from one fair shuffle of the 52.")
+}
+
+// checkDeal checks a peer-to-peer hand once it is over
+func (g *game) checkDeal() {
+	if g.mental != nil {
+		g.mental.verify(g)
+	}
+}
+
+// peerSeat is a player at another process, whose decisions arrive as messages
+type peerSeat struct {
+	t *mentalTable
+}
+
+func (s peerSeat) decide(g *game, seat int) decision {
+	fmt.Fprintf(g.out, "Waiting for %s...\n", g.players[seat].name)
+	args := s.t.expect(seat, "ACTION")
+	if len(args) == 0 {
+		return decision{action: fold}
+	}
+	d, err := parseAction(strings.Join(args, " "))
+	if err != nil {
+		return decision{action: fold}
+	}
+	return d
+}
+
+func (s peerSeat) discard(g *game, seat int) []int {
+	var positions []int
+	for _, arg := range s.t.expect(seat, "DISCARD") {
+		if pos, err := strconv.Atoi(arg); err == nil {
+			positions = append(positions, pos-1)
+		}
+	}
+	return positions
+}
+
+// localSeat is the player at this process, whose decisions are sent to the
+// others as they are made
+type localSeat struct {
+	strategy
+	t *mentalTable
+}
+
+func (s localSeat) decide(g *game, seat int) decision {
+	d := s.strategy.decide(g, seat)
+	switch d.action {
+	case fold:
+		s.t.send("ACTION", "FOLD")
+	case call:
+		s.t.send("ACTION", "CALL")
+	default:
+		s.t.send("ACTION", "RAISE", strconv.Itoa(d.amount))
+	}
+	return d
+}
+
+func (s localSeat) discard(g *game, seat int) []int {
+	positions := s.strategy.discard(g, seat)
+	args := make([]string, len(positions))
+	for i, pos := range positions {
+		args[i] = strconv.Itoa(pos + 1)
+	}
+	s.t.send("DISCARD", args...)
+	return positions
+}
+
+// Short fingerprint of a player's signing key, for players to compare
+func fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// playMental joins a peer-to-peer game through a relay and plays it with a
+// strategy until one player has all the chips, or for a number of hands
+func playMental(conn net.Conn, name string, local strategy, out io.Writer, hands int) (err error) {
+	public, signing, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(conn, "HELLO %s %s\n", name, base64.StdEncoding.EncodeToString(public))
+	fmt.Fprintln(out, "Waiting for the other players...")
+
+	in := bufio.NewReader(conn)
+	line, err := in.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(line)
+	if len(fields) > 0 && fields[0] == "ERROR" {
+		return errors.New(strings.Join(fields[1:], " "))
+	}
+	if len(fields) < 5 || fields[0] != "ROSTER" {
+		return fmt.Errorf("the relay sent %q", strings.TrimSpace(line))
+	}
+	stack, err1 := strconv.Atoi(fields[1])
+	seat, err2 := strconv.Atoi(fields[2])
+	if err1 != nil || err2 != nil || seat < 0 || seat >= len(fields)-3 {
+		return fmt.Errorf("the relay sent %q", strings.TrimSpace(line))
+	}
+
+	t := &mentalTable{seat: seat, signing: signing, conn: conn, in: in}
+	g := newGame()
+	g.out = out
+	g.players = nil
+	g.mental = t
+	fmt.Fprintln(out, "Players, with their key fingerprints. Check them with each other:")
+	for i, entry := range fields[3:] {
+		playerName, keyText, _ := strings.Cut(entry, ":")
+		key, err := base64.StdEncoding.DecodeString(keyText)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("the relay sent a bad key for %s", playerName)
+		}
+		t.names = append(t.names, playerName)
+		t.keys = append(t.keys, key)
+		t.heard = append(t.heard, 0)
+
+		var s strategy = peerSeat{t}
+		if i == seat {
+			s = localSeat{local, t}
+		}
+		g.players = append(g.players, player{name: playerName, chips: stack, strategy: s})
+		fmt.Fprintf(out, "  %d. %s %s\n", i+1, playerName, fingerprint(key))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			abort, ok := r.(mentalAbort)
+			if !ok {
+				panic(r)
+			}
+			err = abort.err
+		}
+	}()
+	for played := 0; g.playersWithChips() > 1 && (hands == 0 || played < hands); played++ {
+		playHand(g)
+		g.resetRound()
+	}
+	if winner := g.lastPlayerWithChips(); winner >= 0 && g.playersWithChips() == 1 {
+		fmt.Fprintf(out, "%s wins the game!\n", g.players[winner].name)
+	}
+	return nil
+}
+
+// Seat of a player with chips, or -1
+func (g *game) lastPlayerWithChips() int {
+	for i, p := range g.players {
+		if p.chips > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// runMental is the mental subcommand: a peer-to-peer game with no dealer.
+// One player runs the relay with -listen and everyone else connects to it.
+func runMental(args []string) error {
+	flags := flag.NewFlagSet("mental", flag.ExitOnError)
+	listen := flags.String("listen", "", "run the relay on this address, such as :7800, and play through it")
+	addr := flags.String("addr", "localhost:7800", "address of the relay to play through")
+	players := flags.Int("players", 2, "players the relay waits for before dealing")
+	stack := flags.Int("stack", 1000, "chips for each player")
+	name := flags.String("name", "", "your name at the table")
+	bot := flags.Bool("bot", false, "let the computer play your seat")
+	flags.Parse(args)
+
+	if !validName.MatchString(*name) {
+		return errors.New("-name must be 1-16 letters, digits, - or _")
+	}
+	if *listen != "" {
+		if *players < 2 || *players > maxPeers {
+			return fmt.Errorf("players must be between 2 and %d", maxPeers)
+		}
+		ln, err := net.Listen("tcp", *listen)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Relay listening on %s for %d players\n", ln.Addr(), *players)
+		go runRelay(ln, *players, *stack)
+		*addr = ln.Addr().String()
+	}
+
+	conn, err := net.Dial("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var local strategy = consolePlayer{}
+	if *bot {
+		local = simpleBot{}
+	}
+	return playMental(conn, *name, local, os.Stdout, 0)
+}
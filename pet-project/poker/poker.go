@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -13,35 +17,76 @@ type deck []string
 
 // Card represents a single playing card
 type card struct {
-	value int    // 2-14 (where 11=Jack, 12=Queen, 13=King, 14=Ace)
+	value int    // 2-14 (where 11=Jack, 12=Queen, 13=King, 14=Ace), 0 for a joker
 	suit  string
 }
 
 // HandRank represents the strength of a poker hand
 type handRank struct {
-	rank     int    // 1=High Card, 2=Pair, 3=Two Pair, etc.
+	rank     int    // 1=High Card, 2=Pair, 3=Two Pair, etc. up to 11=Five of a Kind
 	rankName string
 	values   []int  // For tie-breaking
 }
 
 // Player represents a poker player
 type player struct {
-	name   string
-	hand   deck
-	chips  int
-	bet    int
-	folded bool
+	name     string
+	hand     deck
+	chips    int
+	bet      int // chips put in during the current betting round
+	total    int // chips put in during the whole hand, dead money included
+	drawn    int // cards replaced at the draw this hand
+	folded   bool
+	strategy strategy
+
+	sittingOut   bool // not dealt in until they come back
+	missedBlinds bool // the big blind passed them while sitting out
+
+	bankUsed time.Duration // time bank used up, carried from hand to hand
 }
 
 // Game represents the poker game state
 type game struct {
+	name       string // table name in a multi-table tournament
 	players    []player
 	deck       deck
 	pot        int
 	round      string
+	phase      handPhase // where the hand is, moved on by playHand
+	ledger     ledger    // every chip moved this hand
+	house      *house    // takes rake at a hosted table, nil for none
 	smallBlind int
 	bigBlind   int
-	dealer     int // 0 or 1, alternates each hand
+	dealer     int       // moves to the next player with chips each hand
+	wilds      wildRules // jokers and wild cards in play
+	betting    bettingRules
+	raises     int    // bets and raises made in the current round
+	lastRaise  int    // size of the last raise, the smallest allowed re-raise
+	closed     []bool // seats that have acted since the last full raise, who may only call or fold
+	muck       deck   // discards, reshuffled if the deck runs out at the draw
+
+	// Forced bets
+	ante         int  // dead money from every player, or from the big blind alone
+	bigBlindAnte bool // the big blind posts the ante for the whole table
+	straddles    bool // the player after the big blind may straddle
+	deadBlinds   deadBlindPolicy
+	bigBlindSeat int // -1 before the first hand
+	straddleSeat int // -1 when nobody straddled this hand
+
+	// The clock for players who can be timed
+	timeout  time.Duration // to make each decision, 0 for no clock
+	timeBank time.Duration // extra time each player can draw on over the game
+
+	out      io.Writer     // where the table's play-by-play is written
+	watchers []handWatcher // see every player's cards, as commentators do
+	revealed bool          // hands have been shown down this hand
+	fair     *fairDealer   // commits to each shuffle, nil for an ordinary one
+	mental   *mentalTable  // deals a deck no one player can see, nil for a local one
+	screen   *tui          // draws the table full screen, nil for plain text
+	advisor  bool          // show the human the odds before each decision
+	coach    *coach        // goes over the human's decisions, nil when off
+	stats    *statsKeeper  // counts the stats of players with profiles, nil for none
+	rng      *rand.Rand    // shuffles and makes the computers' choices, nil for a fresh seed each time
 }
 
 func newDeck() deck {
@@ -59,10 +104,14 @@ func newDeck() deck {
 
 func (d deck) shuffle() {
 	source := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(source)
+	d.shuffleWith(rand.New(source))
+}
 
-	for i := range d {
-		newPosition := r.Intn(len(d) - 1)
+// shuffleWith shuffles the deck with a Fisher-Yates shuffle, so the same
+// generator in the same state always gives the same order
+func (d deck) shuffleWith(r *rand.Rand) {
+	for i := len(d) - 1; i > 0; i-- {
+		newPosition := r.Intn(i + 1)
 		d[i], d[newPosition] = d[newPosition], d[i]
 	}
 }
@@ -75,11 +124,32 @@ func deal(d deck, handSize int) (deck, deck) {
 	return d[:handSize], d[handSize:]
 }
 
-// Convert string card to card struct
+// Convert string card to card struct. The engine only parses cards it dealt
+// itself, so one it can't read is a bug.
 func parseCard(cardStr string) card {
-	parts := strings.Split(cardStr, " of ")
-	valueName := parts[0]
-	suit := parts[1]
+	c, err := readCard(cardStr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// readCard reads a card written the way the deck writes them, such as
+// "Ace of Spades", or a joker
+func readCard(cardStr string) (card, error) {
+	if cardStr == jokerCard {
+		return card{value: 0, suit: jokerSuit}, nil
+	}
+
+	valueName, suit, ok := strings.Cut(cardStr, " of ")
+	if !ok {
+		return card{}, fmt.Errorf("%q isn't a card", cardStr)
+	}
+	switch suit {
+	case "Spades", "Diamonds", "Hearts", "Clubs":
+	default:
+		return card{}, fmt.Errorf("%q isn't a card: no such suit as %q", cardStr, suit)
+	}
 	
 	var value int
 	switch valueName {
@@ -109,9 +179,11 @@ func parseCard(cardStr string) card {
 		value = 13
 	case "Ace":
 		value = 14
+	default:
+		return card{}, fmt.Errorf("%q isn't a card: no such value as %q", cardStr, valueName)
 	}
 	
-	return card{value: value, suit: suit}
+	return card{value: value, suit: suit}, nil
 }
 
 // Convert deck to cards for evaluation
@@ -169,6 +241,7 @@ func evaluateHand(cards []card) handRank {
 	var pairs []int
 	var trips []int
 	var quads []int
+	var fives []int
 	
 	for value, count := range valueCounts {
 		switch count {
@@ -178,6 +251,8 @@ func evaluateHand(cards []card) handRank {
 			trips = append(trips, value)
 		case 4:
 			quads = append(quads, value)
+		case 5:
+			fives = append(fives, value)
 		}
 	}
 	
@@ -186,6 +261,10 @@ func evaluateHand(cards []card) handRank {
 	sort.Sort(sort.Reverse(sort.IntSlice(trips)))
 	
 	// Determine hand rank
+	if len(fives) > 0 { // Only possible with wild cards
+		return handRank{rank: 11, rankName: "Five of a Kind", values: fives}
+	}
+	
 	if isStraight && isFlush {
 		if values[0] == 14 && values[1] == 13 { // Royal flush
 			return handRank{rank: 10, rankName: "Royal Flush", values: values}
@@ -267,218 +346,445 @@ func compareHands(hand1, hand2 handRank) int {
 func newGame() *game {
 	g := &game{
 		players: []player{
-			{name: "You", chips: 1000, folded: false},
-			{name: "Computer", chips: 1000, folded: false},
+			{name: "You", chips: 1000, folded: false, strategy: consolePlayer{}},
+			{name: "Computer", chips: 1000, folded: false, strategy: newAdaptiveBot()},
 		},
-		deck:       newDeck(),
-		pot:        0,
-		round:      "pre-flop",
-		smallBlind: 25,
-		bigBlind:   50,
-		dealer:     0, // Player starts as dealer
-	}
-	g.deck.shuffle()
+		pot:          0,
+		round:        preDraw,
+		smallBlind:   25,
+		bigBlind:     50,
+		dealer:       0, // Player starts as dealer
+		betting:      bettingRules{structure: noLimit, smallBet: 50, bigBet: 100, raiseCap: 4},
+		bigBlindSeat: -1,
+		straddleSeat: -1,
+		out:          os.Stdout,
+	}
 	return g
 }
 
-func (g *game) postBlinds() {
-	fmt.Println("\n=== Posting Blinds ===")
-	
-	// Determine who posts what based on dealer position
-	var smallBlindPlayer, bigBlindPlayer int
-	if g.dealer == 0 {
-		// Player is dealer, so player posts small blind, computer posts big blind
-		smallBlindPlayer = 0
-		bigBlindPlayer = 1
-	} else {
-		// Computer is dealer, so computer posts small blind, player posts big blind
-		smallBlindPlayer = 1
-		bigBlindPlayer = 0
+// Add computer opponents, numbering them once there is more than one
+func (g *game) addOpponents(count int) {
+	if count <= 1 {
+		return
+	}
+	g.players[1].name = "Computer 1"
+	for i := 2; i <= count; i++ {
+		g.players = append(g.players, player{name: fmt.Sprintf("Computer %d", i), chips: 1000, strategy: newAdaptiveBot()})
+	}
+}
+
+// Seats posting the small and big blind
+func (g *game) blindSeats() (int, int) {
+	smallBlindPlayer := g.dealer
+	if g.activeCount() > 2 || g.players[g.dealer].folded {
+		smallBlindPlayer = g.nextInHand(g.dealer)
 	}
+	// Heads-up the dealer posts the small blind
+	return smallBlindPlayer, g.nextInHand(smallBlindPlayer)
+}
+
+func (g *game) postBlinds() {
+	fmt.Fprintln(g.out, "\n=== Posting Blinds ===")
+	g.openLedger()
+	
+	// Determine who is dealt in and who posts what based on dealer position
+	g.seatPlayers()
+	g.collectTime()
+	smallBlindPlayer, bigBlindPlayer := g.blindSeats()
+	g.markMissedBlinds(bigBlindPlayer)
+	g.bigBlindSeat = bigBlindPlayer
+	g.straddleSeat = -1
+	g.postAntes(bigBlindPlayer)
 	
 	// Post small blind
 	smallBlindAmount := g.smallBlind
 	if smallBlindAmount > g.players[smallBlindPlayer].chips {
 		smallBlindAmount = g.players[smallBlindPlayer].chips
 	}
-	g.players[smallBlindPlayer].chips -= smallBlindAmount
-	g.players[smallBlindPlayer].bet = smallBlindAmount
-	g.pot += smallBlindAmount
-	fmt.Printf("%s posts small blind: %d chips\n", g.players[smallBlindPlayer].name, smallBlindAmount)
+	g.commit(smallBlindPlayer, smallBlindAmount, blindEntry)
+	fmt.Fprintf(g.out, "%s posts small blind: %d chips\n", g.players[smallBlindPlayer].name, smallBlindAmount)
 	
 	// Post big blind
 	bigBlindAmount := g.bigBlind
 	if bigBlindAmount > g.players[bigBlindPlayer].chips {
 		bigBlindAmount = g.players[bigBlindPlayer].chips
 	}
-	g.players[bigBlindPlayer].chips -= bigBlindAmount
-	g.players[bigBlindPlayer].bet = bigBlindAmount
-	g.pot += bigBlindAmount
-	fmt.Printf("%s posts big blind: %d chips\n", g.players[bigBlindPlayer].name, bigBlindAmount)
+	g.commit(bigBlindPlayer, bigBlindAmount, blindEntry)
+	fmt.Fprintf(g.out, "%s posts big blind: %d chips\n", g.players[bigBlindPlayer].name, bigBlindAmount)
+	
+	g.postMissedBlinds(smallBlindPlayer, bigBlindPlayer)
+	g.offerStraddle(bigBlindPlayer)
 	
-	fmt.Printf("Pot after blinds: %d chips\n", g.pot)
+	fmt.Fprintf(g.out, "Pot after blinds: %d chips\n", g.pot)
 }
 
 func (g *game) dealHands() {
+	if g.mental != nil {
+		g.mental.dealHands(g)
+		return
+	}
+	// A fresh deck every hand, so changes to the wild rules take effect
+	g.deck = newDeckWithJokers(g.wilds.jokers)
+	if g.fair != nil {
+		g.fair.shuffle(g)
+	} else if g.rng != nil {
+		g.deck.shuffleWith(g.rng)
+	} else {
+		g.deck.shuffle()
+	}
+	
 	for i := range g.players {
+		if g.players[i].folded { // Sitting out or out of chips
+			continue
+		}
 		hand, remaining := deal(g.deck, 5)
 		g.players[i].hand = hand
 		g.deck = remaining
 	}
 }
 
+// Seat of the human at the keyboard, or -1 at a table of computers
+func (g *game) humanSeat() int {
+	for i := range g.players {
+		if g.isHuman(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *game) isHuman(seat int) bool {
+	s := g.players[seat].strategy
+	if local, ok := s.(localSeat); ok {
+		s = local.strategy
+	}
+	_, ok := s.(consolePlayer)
+	return ok
+}
+
 func (g *game) showPlayerHand() {
-	fmt.Println("\n=== Your Hand ===")
-	fmt.Println(g.players[0].hand.toString())
+	for i, p := range g.players {
+		if p.folded {
+			continue
+		}
+		if w, ok := p.strategy.(handWatcher); ok {
+			w.seeHand(g, i)
+		}
+		for _, w := range g.watchers {
+			w.seeHand(g, i)
+		}
+	}
+	
+	seat := g.humanSeat()
+	if seat < 0 || g.players[seat].folded {
+		return
+	}
+	you := g.players[seat]
+	
+	fmt.Fprintln(g.out, "\n=== Your Hand ===")
+	fmt.Fprintln(g.out, you.hand.toString())
 	
 	// Show hand strength
-	playerCards := g.players[0].hand.toCards()
-	playerRank := evaluateHand(playerCards)
-	fmt.Printf("Your hand: %s\n", playerRank.rankName)
+	playerRank := g.evaluate(you.hand)
+	fmt.Fprintf(g.out, "Your hand: %s\n", playerRank.rankName)
 	
-	fmt.Printf("Your chips: %d\n", g.players[0].chips)
-	fmt.Printf("Current pot: %d\n", g.pot)
-	fmt.Printf("Your current bet: %d\n", g.players[0].bet)
+	fmt.Fprintf(g.out, "Your chips: %d\n", you.chips)
+	fmt.Fprintf(g.out, "Current pot: %d\n", g.pot)
+	fmt.Fprintf(g.out, "Your current bet: %d\n", you.bet)
 }
 
-func (g *game) playerAction() string {
-	fmt.Println("\nWhat would you like to do?")
-	fmt.Println("1. Bet/Raise")
-	fmt.Println("2. Call")
-	fmt.Println("3. Fold (WARNING: You'll lose your blinds/bets!)")
-	fmt.Print("Enter your choice (1-3): ")
+func (g *game) playerAction(ctx context.Context, seat int) (string, error) {
+	callLabel := "Check"
+	if amount := g.toCall(seat); amount > 0 {
+		callLabel = fmt.Sprintf("Call %d", amount)
+	}
+	
+	fmt.Fprintln(g.out, "\nWhat would you like to do?")
+	fmt.Fprintln(g.out, "1. Bet/Raise")
+	fmt.Fprintf(g.out, "2. %s\n", callLabel)
+	fmt.Fprintln(g.out, "3. Fold (WARNING: You'll lose your blinds/bets!)")
+	fmt.Fprintf(g.out, "Enter your choice (1-3)%s: ", timeToAct(ctx))
 	
-	var choice string
-	fmt.Scanln(&choice)
-	return choice
+	choice, err := consoleInput().readLine(ctx)
+	return strings.TrimSpace(choice), err
 }
 
-func (g *game) playerBet() {
-	currentBet := g.players[1].bet // Computer's current bet
-	minRaise := currentBet + g.bigBlind
-	
-	fmt.Printf("Current bet to call: %d\n", currentBet)
-	fmt.Printf("Minimum raise: %d\n", minRaise)
-	fmt.Printf("How much would you like to bet? (Max: %d): ", g.players[0].chips+g.players[0].bet)
-	
-	var betAmount int
-	fmt.Scanln(&betAmount)
-	
-	// Calculate additional chips needed
-	additionalBet := betAmount - g.players[0].bet
+func (g *game) playerBet(ctx context.Context, seat int) (decision, error) {
+	minRaise, maxRaise, err := g.raiseBounds(seat)
+	if err != nil {
+		fmt.Fprintf(g.out, "You can't raise: %v. You call instead.\n", err)
+		return decision{action: call}, nil
+	}
 	
-	if additionalBet > g.players[0].chips {
-		additionalBet = g.players[0].chips
-		betAmount = g.players[0].bet + additionalBet
-		fmt.Printf("Betting all remaining chips. Total bet: %d\n", betAmount)
+	fmt.Fprintf(g.out, "Current bet to call: %d\n", g.highestBet())
+	if minRaise == maxRaise {
+		// Fixed-limit, or not enough chips for more than one size
+		fmt.Fprintf(g.out, "%s: you raise to %d\n", g.betting.structure, minRaise)
+		return decision{action: raise, amount: minRaise}, nil
 	}
+	fmt.Fprintf(g.out, "Minimum raise: %d\n", minRaise)
 	
-	g.players[0].chips -= additionalBet
-	g.players[0].bet = betAmount
-	g.pot += additionalBet
-	fmt.Printf("You bet %d chips total. Pot is now %d\n", betAmount, g.pot)
+	for {
+		fmt.Fprintf(g.out, "How much would you like to bet? (Max: %d)%s: ", maxRaise, timeToAct(ctx))
+		
+		line, err := consoleInput().readLine(ctx)
+		if err == io.EOF {
+			return decision{action: call}, nil
+		} else if err != nil {
+			return decision{}, err
+		}
+		betAmount, _ := strconv.Atoi(strings.TrimSpace(line))
+		
+		if betAmount > g.players[seat].chips+g.players[seat].bet {
+			betAmount = g.players[seat].chips + g.players[seat].bet
+			fmt.Fprintf(g.out, "Betting all remaining chips. Total bet: %d\n", betAmount)
+		}
+		
+		d := decision{action: raise, amount: betAmount}
+		if err := g.validate(seat, d); err != nil {
+			fmt.Fprintf(g.out, "Invalid bet: %v. Try again.\n", err)
+			continue
+		}
+		return d, nil
+	}
 }
 
-func (g *game) computerAction() {
-	// Simple AI based on hand strength
-	computerCards := g.players[1].hand.toCards()
-	computerRank := evaluateHand(computerCards)
+// simpleBot plays the computer's fixed hand-strength strategy
+type simpleBot struct{}
+
+func (simpleBot) decide(g *game, seat int) decision {
+	return g.computerAction(seat)
+}
+
+func (simpleBot) discard(g *game, seat int) []int {
+	return g.computerDiscards(seat)
+}
+
+// thresholds are the chances the computer's hand-strength play is built on
+type thresholds struct {
+	strongRaise float64 // raise, rather than call, with Two Pair to a Straight
+	pairFold    float64 // fold One Pair, or check it when that's free
+	pairRaise   float64 // raise One Pair
+	bluff       float64 // bet or call with High Card
+	bluffRaise  float64 // share of those bluffs that raise rather than call
+	catchBluff  float64 // call a bet with High Card, hoping to catch a bluff
+	sizing      float64 // scales the size of bets and raises
+}
+
+// The computer's fixed play, which pays no attention to the opponents
+var fixedThresholds = thresholds{strongRaise: 0.5, pairFold: 1.0 / 3, pairRaise: 1.0 / 3, bluff: 0.25, bluffRaise: 0.5, sizing: 1}
+
+func (g *game) computerAction(seat int) decision {
+	return g.thresholdAction(seat, fixedThresholds)
+}
+
+// random draws a number in [0, 1) for a computer's choice
+func (g *game) random() float64 {
+	if g.rng != nil {
+		return g.rng.Float64()
+	}
+	return rand.Float64()
+}
+
+// intn draws a number in [0, n) for seating players and the like
+func (g *game) intn(n int) int {
+	if g.rng != nil {
+		return g.rng.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+// thresholdAction plays a seat by hand strength with the given chances
+func (g *game) thresholdAction(seat int, t thresholds) decision {
+	computerRank := g.evaluate(g.players[seat].hand)
 	
-	currentBet := g.players[0].bet
-	callAmount := currentBet - g.players[1].bet
+	currentBet := g.highestBet()
+	callAmount := g.toCall(seat)
 	
 	// AI decision based on hand strength
 	var action int
+	r := g.random()
 	if computerRank.rank >= 6 { // Flush or better - always bet/raise
 		action = 2
 	} else if computerRank.rank >= 3 { // Two pair or better - usually bet/call
-		action = rand.Intn(2) + 1 // 1 or 2
+		action = 1
+		if r < t.strongRaise {
+			action = 2
+		}
 	} else if computerRank.rank == 2 { // One pair - mixed strategy
-		action = rand.Intn(3)
+		switch {
+		case r < t.pairFold:
+			action = 0
+		case r < t.pairFold+t.pairRaise:
+			action = 2
+		default:
+			action = 1
+		}
 	} else { // High card - usually fold
-		if rand.Intn(4) == 0 { // 25% chance to bluff
-			action = rand.Intn(2) + 1
+		if r < t.bluff {
+			action = 1
+			if g.random() < t.bluffRaise {
+				action = 2
+			}
+		} else if callAmount > 0 && r < t.bluff+t.catchBluff {
+			action = 1
 		} else {
 			action = 0 // fold
 		}
 	}
 	
 	switch action {
-	case 0: // Fold
-		fmt.Printf("Computer folds! (Loses %d chips already bet)\n", g.players[1].bet)
-		g.players[1].folded = true
-	case 1: // Call
-		if callAmount > g.players[1].chips {
-			callAmount = g.players[1].chips
-		}
-		g.players[1].chips -= callAmount
-		g.players[1].bet += callAmount
-		g.pot += callAmount
-		fmt.Printf("Computer calls with %d chips. Pot is now %d\n", callAmount, g.pot)
-	case 2: // Bet/Raise
-		raiseAmount := 50 + (computerRank.rank * 20) // Bet more with better hands
-		totalBet := currentBet + raiseAmount
-		additionalBet := totalBet - g.players[1].bet
-		
-		if additionalBet > g.players[1].chips {
-			additionalBet = g.players[1].chips
-			totalBet = g.players[1].bet + additionalBet
+	case 0: // Fold, unless checking is free
+		if callAmount == 0 {
+			return decision{action: call}
 		}
-		
-		g.players[1].chips -= additionalBet
-		g.players[1].bet = totalBet
-		g.pot += additionalBet
-		fmt.Printf("Computer raises to %d chips. Pot is now %d\n", totalBet, g.pot)
+		return decision{action: fold}
+	case 1: // Call
+		return decision{action: call}
+	default: // Bet/Raise, kept within the betting structure
+		raiseAmount := int(float64(50+computerRank.rank*20) * t.sizing) // Bet more with better hands
+		return g.clampRaise(seat, currentBet+raiseAmount)
 	}
 }
 
 func (g *game) showdown() {
-	fmt.Println("\n=== SHOWDOWN ===")
-	
-	playerCards := g.players[0].hand.toCards()
-	computerCards := g.players[1].hand.toCards()
-	
-	playerRank := evaluateHand(playerCards)
-	computerRank := evaluateHand(computerCards)
-	
-	fmt.Printf("Your hand: %s (%s)\n", g.players[0].hand.toString(), playerRank.rankName)
-	fmt.Printf("Computer hand: %s (%s)\n", g.players[1].hand.toString(), computerRank.rankName)
+	fmt.Fprintln(g.out, "\n=== SHOWDOWN ===")
+	g.revealed = true
+	if g.mental != nil {
+		g.mental.showHands(g)
+	}
 	
-	result := compareHands(playerRank, computerRank)
+	ranks := make([]handRank, len(g.players))
+	for i, p := range g.players {
+		if p.folded {
+			continue
+		}
+		ranks[i] = g.evaluate(p.hand)
+		fmt.Fprintf(g.out, "%s: %s (%s)\n", g.handLabel(i), p.hand.toString(), ranks[i].rankName)
+	}
 	
-	if result > 0 {
-		fmt.Println("You win the hand!")
-		g.players[0].chips += g.pot
-	} else if result < 0 {
-		fmt.Println("Computer wins the hand!")
-		g.players[1].chips += g.pot
-	} else {
-		fmt.Println("It's a tie! Pot is split.")
-		splitPot := g.pot / 2
-		g.players[0].chips += splitPot
-		g.players[1].chips += (g.pot - splitPot) // Handle odd pots
+	// Each side pot goes to the best hand among the players who paid into it
+	for _, pot := range rakePots(g.sidePots(), g.takeRake()) {
+		if pot.amount == 0 {
+			continue // all raked
+		}
+		winners := []int{pot.eligible[0]}
+		for _, seat := range pot.eligible[1:] {
+			result := compareHands(ranks[seat], ranks[winners[0]])
+			if result > 0 {
+				winners = []int{seat}
+			} else if result == 0 {
+				winners = append(winners, seat)
+			}
+		}
+		
+		if len(winners) == 1 {
+			fmt.Fprintf(g.out, "%s wins %s of %d chips!\n", g.players[winners[0]].name, pot.name, pot.amount)
+		} else {
+			fmt.Fprintf(g.out, "It's a tie! %s of %d chips is split.\n", pot.name, pot.amount)
+		}
+		
+		// Odd chips go to the first winners after the dealer
+		share := pot.amount / len(winners)
+		odd := pot.amount % len(winners)
+		for _, seat := range winners {
+			won := share
+			if odd > 0 {
+				won++
+				odd--
+			}
+			g.pay(awardEntry, seat, won)
+		}
 	}
 	
-	fmt.Printf("Your chips: %d\n", g.players[0].chips)
-	fmt.Printf("Computer chips: %d\n", g.players[1].chips)
+	for i, p := range g.players {
+		if !p.folded {
+			fmt.Fprintf(g.out, "%s: %d\n", g.chipLabel(i), p.chips)
+		}
+	}
+}
+
+// Label for a seat's hand, "Your hand" for the human
+func (g *game) handLabel(seat int) string {
+	if g.isHuman(seat) {
+		return "Your hand"
+	}
+	return g.players[seat].name + " hand"
+}
+
+// Label for a seat's chip count, "Your chips" for the human
+func (g *game) chipLabel(seat int) string {
+	if g.isHuman(seat) {
+		return "Your chips"
+	}
+	return g.players[seat].name + " chips"
 }
 
 func (g *game) resetRound() {
 	g.pot = 0
-	g.round = "pre-flop"
-	
-	// Switch dealer
-	g.dealer = 1 - g.dealer
+	g.clearHand()
 	
+	// Move the dealer button to the next player still in the game
+	for i := 1; i <= len(g.players); i++ {
+		seat := (g.dealer + i) % len(g.players)
+		if g.players[seat].chips > 0 {
+			g.dealer = seat
+			break
+		}
+	}
+}
+
+// clearHand forgets the last hand's bets, cards and ledger. The blinds do it
+// too, so a hand starts clean whether or not resetRound ran after the last.
+func (g *game) clearHand() {
+	g.ledger = ledger{}
+	g.round = preDraw
+	g.revealed = false
+	g.closed = nil
 	for i := range g.players {
 		g.players[i].bet = 0
+		g.players[i].total = 0
+		g.players[i].drawn = 0
 		g.players[i].folded = false
 		g.players[i].hand = deck{}
 	}
-	g.deck = newDeck()
-	g.deck.shuffle()
+	g.muck = deck{}
 }
 
+// Chip counts for every player, such as "You: 950, Computer: 1050"
+func (g *game) chipCounts() string {
+	counts := make([]string, len(g.players))
+	for i, p := range g.players {
+		counts[i] = fmt.Sprintf("%s: %d", p.name, p.chips)
+	}
+	return strings.Join(counts, ", ")
+}
+
+// Number of players who still have chips
+func (g *game) playersWithChips() int {
+	count := 0
+	for _, p := range g.players {
+		if p.chips > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// The game ends when you are out of chips or have beaten every computer, or
+// when you're only watching, once one computer has all the chips
 func (g *game) isGameOver() bool {
-	return g.players[0].chips <= 0 || g.players[1].chips <= 0
+	you := g.humanSeat()
+	if you < 0 {
+		return g.playersWithChips() <= 1
+	}
+	if g.players[you].chips <= 0 {
+		return true
+	}
+	for i, p := range g.players {
+		if i != you && p.chips > 0 {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewDeckWithJokers(t *testing.T) {
+	d := newDeckWithJokers(2)
+
+	if len(d) != 54 {
+		t.Errorf("Expected deck length of 54, but got %v", len(d))
+	}
+
+	if d[len(d)-1] != jokerCard {
+		t.Errorf("Expected last card to be a joker, but got %v", d[len(d)-1])
+	}
+}
+
+func TestEvaluateWildHand(t *testing.T) {
+	tests := []struct {
+		hand  deck
+		rules wildRules
+		rank  int
+	}{
+		{deck{"Ace of Spades", "Ace of Hearts", "Ace of Clubs", "Ace of Diamonds", jokerCard}, wildRules{jokers: 1}, 11},
+		{deck{"Two of Spades", "Two of Hearts", "Nine of Clubs", "Nine of Diamonds", "Nine of Hearts"}, wildRules{deucesWild: true}, 11},
+		{deck{"Ten of Hearts", "Jack of Hearts", "Queen of Hearts", "King of Hearts", jokerCard}, wildRules{jokers: 1}, 10},
+		{deck{"Two of Spades", "Seven of Clubs", "Seven of Hearts", "Four of Diamonds", "Nine of Spades"}, wildRules{deucesWild: true}, 4},
+		{deck{"Two of Spades", "Seven of Clubs", "Seven of Hearts", "Four of Diamonds", "Nine of Spades"}, wildRules{}, 2},
+		{deck{jokerCard, "Seven of Clubs", "Seven of Hearts", "Four of Diamonds", "Nine of Spades"}, wildRules{jokers: 1, bug: true}, 2},
+		{deck{jokerCard, "Ace of Clubs", "Seven of Hearts", "Four of Diamonds", "Nine of Spades"}, wildRules{jokers: 1, bug: true}, 2},
+		{deck{jokerCard, "Five of Clubs", "Six of Hearts", "Seven of Diamonds", "Eight of Spades"}, wildRules{jokers: 1, bug: true}, 5},
+		{deck{jokerCard, jokerCard, "Two of Hearts", "Two of Diamonds", "Two of Spades"}, wildRules{jokers: 2, deucesWild: true}, 11},
+	}
+
+	for _, tt := range tests {
+		got := evaluateWildHand(tt.hand.toCards(), tt.rules)
+		if got.rank != tt.rank {
+			t.Errorf("Expected %v with %v to rank %v, but got %v (%v)", tt.hand.toString(), tt.rules, tt.rank, got.rank, got.rankName)
+		}
+	}
+}
+
+func TestWildFlushHasNoDuplicates(t *testing.T) {
+	// The deuce can't be a second Ace of Spades, so it makes the Queen
+	wild := evaluateWildHand(deck{"Ace of Spades", "King of Spades", "Nine of Spades", "Four of Spades", "Two of Hearts"}.toCards(), wildRules{deucesWild: true})
+	if wild.rankName != "Flush" || !reflect.DeepEqual(wild.values, []int{14, 13, 12, 9, 4}) {
+		t.Errorf("Expected an Ace-King-Queen flush, but got %v %v", wild.rankName, wild.values)
+	}
+	natural := evaluateHand(deck{"Ace of Spades", "King of Spades", "Queen of Spades", "Jack of Spades", "Nine of Spades"}.toCards())
+	if compareHands(wild, natural) >= 0 {
+		t.Errorf("Expected the wild flush %v to lose to the natural %v", wild.values, natural.values)
+	}
+
+	// Two jokers can't both be the Ace either
+	wild = evaluateWildHand(deck{jokerCard, jokerCard, "Nine of Hearts", "Seven of Hearts", "Four of Hearts"}.toCards(), wildRules{jokers: 2})
+	if wild.rankName != "Flush" || !reflect.DeepEqual(wild.values, []int{14, 13, 9, 7, 4}) {
+		t.Errorf("Expected an Ace-King flush from the jokers, but got %v %v", wild.rankName, wild.values)
+	}
+}
+
+func TestBugPairsWithAceOnly(t *testing.T) {
+	rules := wildRules{jokers: 1, bug: true}
+	withBug := evaluateWildHand(deck{jokerCard, "Seven of Clubs", "Seven of Hearts", "Four of Diamonds", "Nine of Spades"}.toCards(), rules)
+
+	// The bug pairs neither the sevens nor the nine, so it plays as an Ace kicker
+	if withBug.values[0] != 7 || withBug.values[1] != 14 {
+		t.Errorf("Expected a pair of sevens with an Ace kicker, but got %v", withBug.values)
+	}
+}
+
+func TestSidePots(t *testing.T) {
+	g := newGame()
+	g.addOpponents(2)
+	g.players[0].total = 100 // All in short
+	g.players[1].total = 300
+	g.players[2].total = 300
+	g.pot = 700
+
+	pots := g.sidePots()
+	if len(pots) != 2 {
+		t.Fatalf("Expected a main pot and one side pot, but got %v", len(pots))
+	}
+	if pots[0].amount != 300 || len(pots[0].eligible) != 3 {
+		t.Errorf("Expected a main pot of 300 for 3 players, but got %v for %v", pots[0].amount, pots[0].eligible)
+	}
+	if pots[1].amount != 400 || len(pots[1].eligible) != 2 {
+		t.Errorf("Expected a side pot of 400 for 2 players, but got %v for %v", pots[1].amount, pots[1].eligible)
+	}
+}
+
+func TestAntesAndStraddle(t *testing.T) {
+	g := newGame()
+	g.addOpponents(2)
+	g.ante = 10
+	g.straddles = true
+	g.players[0].strategy = straddleAlways{}
+	g.dealer = 0
+
+	g.postBlinds()
+
+	// Three antes, both blinds and a straddle of 100 from the player after the big blind
+	if g.pot != 30+25+50+100 {
+		t.Errorf("Expected a pot of 205, but got %v", g.pot)
+	}
+	if g.straddleSeat != 0 || g.firstToAct() != 1 {
+		t.Errorf("Expected the straddler to act last, but seat %v straddled and seat %v acts first", g.straddleSeat, g.firstToAct())
+	}
+}
+
+// straddleAlways straddles whenever it is offered
+type straddleAlways struct {
+	simpleBot
+}
+
+func (straddleAlways) straddle(g *game, seat int) bool {
+	return true
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// sidePot is a share of the pot and the players who can win it
+type sidePot struct {
+	name     string
+	amount   int
+	eligible []int // seats still in the hand that paid into it, starting after the dealer
+}
+
+// sidePots splits the pot by how much each remaining player put in, so an all
+// in player can only win what they covered. Dead money and folded players'
+// chips go into the pots they reached.
+func (g *game) sidePots() []sidePot {
+	var levels []int
+	seen := make(map[int]bool)
+	for _, p := range g.players {
+		if !p.folded && !seen[p.total] {
+			seen[p.total] = true
+			levels = append(levels, p.total)
+		}
+	}
+	sort.Ints(levels)
+
+	var pots []sidePot
+	previous := 0
+	for i, level := range levels {
+		amount := 0
+		for _, p := range g.players {
+			upTo := min(p.total, level)
+			if i == len(levels)-1 {
+				upTo = p.total // The last pot sweeps up anything left over
+			}
+			amount += upTo - min(p.total, previous)
+		}
+
+		var eligible []int
+		for seat := g.nextSeat(g.dealer); ; seat = g.nextSeat(seat) {
+			if !g.players[seat].folded && g.players[seat].total >= level {
+				eligible = append(eligible, seat)
+			}
+			if seat == g.dealer {
+				break
+			}
+		}
+
+		if amount > 0 {
+			pots = append(pots, sidePot{amount: amount, eligible: eligible})
+		}
+		previous = level
+	}
+
+	for i := range pots {
+		switch {
+		case len(pots) == 1:
+			pots[i].name = "the pot"
+		case i == 0:
+			pots[i].name = "the main pot"
+		default:
+			pots[i].name = fmt.Sprintf("side pot %d", i)
+		}
+	}
+	return pots
+}
+
+// Seat of the only player left in the hand, or -1 while several are
+func (g *game) lastPlayerStanding() int {
+	if g.activeCount() != 1 {
+		return -1
+	}
+	for i, p := range g.players {
+		if !p.folded {
+			return i
+		}
+	}
+	return -1
+}
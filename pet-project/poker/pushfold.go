@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// pushFoldEV is what the small blind expects to win, in big blinds, by going
+// all in for a stack of `stack` big blinds with one bucket when the big blind
+// calls each bucket as often as `call` says. Folding instead loses the half
+// big blind already posted.
+func pushFoldEV(stack float64, hero int, call []float64, equity [][]float64, freq []float64) float64 {
+	ev := 0.0
+	for villain, f := range freq {
+		showdown := equity[hero][villain]*2*stack - stack
+		ev += f * (call[villain]*showdown + (1-call[villain])*1)
+	}
+	return ev
+}
+
+// callEV is what the big blind expects to win, in big blinds, by calling an
+// all in with one bucket against a pushing range. Folding loses the big blind.
+func callEV(stack float64, hero int, push []float64, equity [][]float64, freq []float64) float64 {
+	ev, weight := 0.0, 0.0
+	for villain, f := range freq {
+		w := f * push[villain]
+		ev += w * (equity[hero][villain]*2*stack - stack)
+		weight += w
+	}
+	if weight == 0 {
+		return 0
+	}
+	return ev / weight
+}
+
+// solvePushFold finds the heads up push or fold equilibrium for a stack of
+// `stack` big blinds by fictitious play: each side keeps playing the best
+// response to the other's average strategy, and the averages settle on the
+// Nash equilibrium. It returns how often each bucket pushes and calls.
+func solvePushFold(stack float64, equity [][]float64, freq []float64, iterations int) ([]float64, []float64) {
+	n := len(freq)
+	push := make([]float64, n)
+	call := make([]float64, n)
+	for i := range push {
+		push[i] = 1 // Start from pushing everything
+	}
+
+	for it := 1; it <= iterations; it++ {
+		step := 1 / float64(it+1)
+		for b := 0; b < n; b++ {
+			best := 0.0
+			if pushFoldEV(stack, b, call, equity, freq) > -0.5 {
+				best = 1
+			}
+			push[b] += step * (best - push[b])
+		}
+		for b := 0; b < n; b++ {
+			best := 0.0
+			if callEV(stack, b, push, equity, freq) > -1 {
+				best = 1
+			}
+			call[b] += step * (best - call[b])
+		}
+	}
+	return push, call
+}
+
+// Share of all hands played by a strategy, and the weakest bucket it plays
+// more often than not
+func rangeSummary(strategy []float64, freq []float64) (float64, string) {
+	share := 0.0
+	weakest := "nothing"
+	for b := len(strategy) - 1; b >= 0; b-- {
+		share += strategy[b] * freq[b]
+		if strategy[b] >= 0.5 {
+			weakest = bucketNames[b]
+		}
+	}
+	return share, weakest
+}
+
+// runPushFold is the pushfold subcommand: it solves heads up push or fold
+// before the draw for every stack size up to -max-stack big blinds
+func runPushFold(args []string) error {
+	flags := flag.NewFlagSet("pushfold", flag.ExitOnError)
+	maxStack := flags.Int("max-stack", 20, "largest stack to solve, in big blinds")
+	trials := flags.Int("trials", 400, "all ins played out for each pair of hand buckets")
+	iterations := flags.Int("iterations", 2000, "fictitious play iterations for each stack size")
+	seed := flags.Int64("seed", 0, "random seed for the equity simulations (0 picks one)")
+	flags.Parse(args)
+
+	if *maxStack < 1 || *trials < 1 || *iterations < 1 {
+		return errors.New("-max-stack, -trials and -iterations must be at least 1")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	fmt.Println("Playing out all ins between hand buckets...")
+	pool := newBucketPool(100000, rng)
+	equity := pool.bucketEquity(*trials, rng)
+
+	fmt.Println("\n=== Heads up push or fold, five card draw ===")
+	fmt.Printf("%-6s %8s  %-22s %8s  %-22s\n", "Stack", "Push", "Weakest push", "Call", "Weakest call")
+	for stack := 1; stack <= *maxStack; stack++ {
+		push, call := solvePushFold(float64(stack), equity, pool.freq, *iterations)
+		pushShare, weakestPush := rangeSummary(push, pool.freq)
+		callShare, weakestCall := rangeSummary(call, pool.freq)
+		fmt.Printf("%-6s %7.1f%%  %-22s %7.1f%%  %-22s\n", fmt.Sprintf("%dBB", stack), 100*pushShare, weakestPush, 100*callShare, weakestCall)
+	}
+	return nil
+}
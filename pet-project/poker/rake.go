@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// house is the rake rules of a hosted table and the account of what it has
+// collected over the session
+type house struct {
+	percent      float64       // of each pot
+	cap          int           // most raked from one pot, 0 for no cap
+	noFlopNoDrop bool          // hands won before the draw aren't raked
+	timeFee      int           // charged to each player dealt in, once every timeEvery
+	timeEvery    time.Duration // 0 for no time charges
+
+	started  time.Time
+	nextTime time.Time // when the next time charge is due, zero for the first hand
+
+	hands      int // hands paid out
+	rakedHands int // hands that paid rake
+	capped     int // hands raked up to the cap
+	rake       int // chips raked from pots
+	time       int // chips charged for time
+}
+
+func newHouse(percent float64, cap int, noFlopNoDrop bool, timeFee int, timeEvery time.Duration) *house {
+	return &house{percent: percent, cap: cap, noFlopNoDrop: noFlopNoDrop, timeFee: timeFee, timeEvery: timeEvery, started: time.Now()}
+}
+
+// takeRake moves the house's cut of the pot to the house and returns it. It
+// is taken once a hand, just before the pot is paid out.
+func (g *game) takeRake() int {
+	h := g.house
+	if h == nil {
+		return 0
+	}
+	h.hands++
+	if h.noFlopNoDrop && g.round == preDraw {
+		return 0
+	}
+	amount := int(float64(g.pot) * h.percent / 100)
+	if h.cap > 0 && amount >= h.cap {
+		amount = h.cap
+		h.capped++
+	}
+	if amount <= 0 {
+		return 0
+	}
+	g.move(rakeEntry, potAccount, houseAccount, amount)
+	h.rake += amount
+	h.rakedHands++
+	fmt.Fprintf(g.out, "The house rakes %d chips from the pot.\n", amount)
+	return amount
+}
+
+// rakePots takes the rake out of the side pots, from the main pot first
+func rakePots(pots []sidePot, rake int) []sidePot {
+	for i := range pots {
+		taken := min(rake, pots[i].amount)
+		pots[i].amount -= taken
+		rake -= taken
+	}
+	return pots
+}
+
+// collectTime takes the time charge from every player dealt in, once each
+// period. Players with no more than the charge left aren't asked for it.
+func (g *game) collectTime() {
+	h := g.house
+	if h == nil || h.timeEvery <= 0 || h.timeFee <= 0 {
+		return
+	}
+	now := time.Now()
+	if now.Before(h.nextTime) {
+		return
+	}
+	h.nextTime = now.Add(h.timeEvery)
+	for seat, p := range g.players {
+		if p.folded || p.chips <= h.timeFee {
+			continue
+		}
+		g.move(timeEntry, seat, houseAccount, h.timeFee)
+		h.time += h.timeFee
+		fmt.Fprintf(g.out, "%s pays %d chips for the next %v at the table.\n", p.name, h.timeFee, h.timeEvery)
+	}
+}
+
+// report writes what the house collected over the session
+func (h *house) report(w io.Writer) {
+	ended := time.Now()
+	length := ended.Sub(h.started).Round(time.Second)
+	fmt.Fprintf(w, "House report for the session from %s to %s (%v)\n",
+		h.started.Format("2006-01-02 15:04"), ended.Format("15:04"), length)
+	fmt.Fprintf(w, "Hands: %d, raked: %d, at the cap: %d\n", h.hands, h.rakedHands, h.capped)
+	fmt.Fprintf(w, "Rake: %d chips", h.rake)
+	if h.rakedHands > 0 {
+		fmt.Fprintf(w, ", %.1f a raked hand", float64(h.rake)/float64(h.rakedHands))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Time charges: %d chips\n", h.time)
+	fmt.Fprintf(w, "Total: %d chips", h.rake+h.time)
+	if length >= time.Minute {
+		fmt.Fprintf(w, ", %.0f an hour", float64(h.rake+h.time)/length.Hours())
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRakeIsCappedPerPot(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.house = newHouse(5, 20, true, 0, 0)
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 300}}}
+	g.players[1].strategy = &scripted{}
+	playHand(g)
+
+	// 5% of the 600 pot is 30, cut back to the cap of 20
+	if g.house.rake != 20 || g.house.capped != 1 || g.house.rakedHands != 1 {
+		t.Errorf("Expected 20 chips raked at the cap, but got %+v", g.house)
+	}
+	if total := g.players[0].chips + g.players[1].chips; total != 1980 {
+		t.Errorf("Expected the players to have 1980 chips left, but got %d", total)
+	}
+	last := g.ledger.entries[len(g.ledger.entries)-1]
+	if rake := g.ledger.entries[len(g.ledger.entries)-2]; rake.kind != rakeEntry || rake.to != houseAccount || last.kind != awardEntry || last.amount != 580 {
+		t.Errorf("Expected the rake to come out before the 580 award, but got %+v and %+v", rake, last)
+	}
+}
+
+func TestNoFlopNoDrop(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.house = newHouse(5, 0, true, 0, 0)
+
+	// Folding before the draw isn't raked
+	g.players[0].strategy = &scripted{decisions: []decision{{action: fold}}}
+	playHand(g)
+	if g.house.hands != 1 || g.house.rake != 0 {
+		t.Errorf("Expected a hand won before the draw not to be raked, but got %+v", g.house)
+	}
+
+	// Folding to a bet after it is, on the 100 chips called
+	g.resetRound()
+	g.resetRound() // back to You in the small blind
+	g.players[0].strategy = &scripted{decisions: []decision{{action: call}, {action: fold}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: call}, {action: raise, amount: 100}}}
+	playHand(g)
+	if g.house.hands != 2 || g.house.rake != 5 {
+		t.Errorf("Expected 5 chips raked from a pot won after the draw, but got %+v", g.house)
+	}
+	if total := g.players[0].chips + g.players[1].chips; total != 1995 {
+		t.Errorf("Expected the players to have 1995 chips left, but got %d", total)
+	}
+}
+
+func TestRakePotsFromTheMainPotFirst(t *testing.T) {
+	pots := rakePots([]sidePot{{amount: 30}, {amount: 200}}, 50)
+	if got := []int{pots[0].amount, pots[1].amount}; !reflect.DeepEqual(got, []int{0, 180}) {
+		t.Errorf("Expected the main pot to be raked first, but got %v", got)
+	}
+}
+
+func TestTimeIsChargedOncePerPeriod(t *testing.T) {
+	g := newGame()
+	g.out = io.Discard
+	g.house = newHouse(0, 0, true, 10, time.Hour)
+	g.players[1].chips = 10 // no more than the charge, so left alone
+	g.postBlinds()
+	if g.house.time != 10 || g.players[0].chips != 965 || g.players[1].chips != 0 {
+		t.Errorf("Expected only You to pay for time, but the house has %d and the stacks are %d and %d",
+			g.house.time, g.players[0].chips, g.players[1].chips)
+	}
+	g.resetRound()
+	g.postBlinds()
+	if g.house.time != 10 {
+		t.Errorf("Expected no more charges within the hour, but the house has %d", g.house.time)
+	}
+
+	var report bytes.Buffer
+	g.house.report(&report)
+	for _, want := range []string{"Time charges: 10 chips", "Total: 10 chips"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("Expected the report to include %q, but got:\n%s", want, report.String())
+		}
+	}
+}
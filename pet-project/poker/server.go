@@ -0,0 +1,810 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// server runs one shared table for remote players, whether they connect over
+// TCP with the line protocol described in PROTOCOL.md or through the HTTP API.
+//
+// Each connection has its own goroutine reading commands, and one more writing
+// to it. The hands are played by the engine goroutine in run. Everything shared,
+// the game above all, is guarded by mu: the engine holds it while it plays a
+// hand and lets go only while it waits for a player to act.
+//
+// A player whose connection drops keeps their seat for a grace period, checking
+// or folding whenever it is their turn, and can pick it up again from a new
+// connection with the session token they were given when they sat down.
+type server struct {
+	mu        sync.Mutex
+	seated    *sync.Cond // signalled when a player joins or the server closes
+	game      *game
+	listeners map[listener]bool // everyone following the play
+	// commentators also see every player's cards, commentaryDelay late
+	commentators    map[listener]bool
+	commentaryDelay time.Duration          // 0 when there is no commentary
+	joining         []player               // seated at the start of the next hand
+	sessions        map[string]*remoteSeat // seated players by session token
+	grace           time.Duration          // a dropped player's seat is kept this long
+	stack           int
+	pause           time.Duration // between hands
+	closed          bool
+	console         io.Writer // the server's own log of the play
+
+	tcp net.Listener
+}
+
+// listener receives the protocol's server to client messages. Callers hold mu.
+type listener interface {
+	send(line string)
+}
+
+// remoteSeat is the strategy for a player who isn't at this terminal. Prompts
+// go out through send and the engine waits for the answers to come back
+// through reply, both in the line protocol.
+type remoteSeat struct {
+	s        *server
+	name     string // empty until seated
+	token    string // the session token, to come back with after a dropped connection
+	send     func(line string)
+	replies  chan string // answers for the engine, while it waits for one
+	waiting  string      // "ACTION" or "DRAW" while it is this player's turn
+	prompt   string      // the message that asked for it
+	deadline time.Time   // when the player runs out of time, zero with no clock
+	gone     chan struct{}
+
+	dropped chan struct{} // closed while the player's connection is down
+	grace   *time.Timer   // gives up the seat if they don't come back in time
+	detach  func()        // lets go of the connection when another takes over
+}
+
+func newRemoteSeat(s *server, send func(line string)) *remoteSeat {
+	return &remoteSeat{s: s, send: send, replies: make(chan string, 1), gone: make(chan struct{}), dropped: make(chan struct{})}
+}
+
+// client is one TCP connection
+type client struct {
+	*remoteSeat
+	conn       net.Conn
+	out        chan string
+	closed     chan struct{}   // closed once the connection is done with
+	commentary *laggedListener // set once the client commentates
+}
+
+// handWatcher is a strategy that wants to see its cards whenever they change
+type handWatcher interface {
+	seeHand(g *game, seat int)
+}
+
+// Most players at a table, bots included
+const maxSeats = 8
+
+// Player names may be used in STATE lines, so they can't have spaces or colons
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,16}$`)
+
+func newServer(base *game, stack int) *server {
+	base.players = nil
+	s := &server{
+		game:         base,
+		listeners:    make(map[listener]bool),
+		commentators: make(map[listener]bool),
+		sessions:     make(map[string]*remoteSeat),
+		grace:        time.Minute,
+		stack:        stack,
+		pause:        3 * time.Second,
+		console:      os.Stdout,
+	}
+	s.seated = sync.NewCond(&s.mu)
+	base.out = &broadcaster{s: s}
+	base.watchers = append(base.watchers, s)
+	base.fair = newFairDealer()
+	return s
+}
+
+// addBots seats computer players who stay for the whole session
+func (s *server) addBots(count int) {
+	for i := 1; i <= count; i++ {
+		name := "Computer"
+		if count > 1 {
+			name = fmt.Sprintf("Computer-%d", i)
+		}
+		s.game.players = append(s.game.players, player{name: name, chips: s.stack, strategy: newAdaptiveBot()})
+	}
+}
+
+// listen accepts connections until the listener is closed
+func (s *server) listen(ln net.Listener) {
+	s.mu.Lock()
+	s.tcp = ln
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		c := &client{conn: conn, out: make(chan string, 256), closed: make(chan struct{})}
+		c.remoteSeat = newRemoteSeat(s, c.send)
+		s.mu.Lock()
+		s.listeners[c] = true
+		s.mu.Unlock()
+
+		go c.writeLoop()
+		go c.readLoop()
+	}
+}
+
+// run plays hands for as long as the server is open, waiting whenever fewer
+// than two players have chips or nobody but the bots is seated
+func (s *server) run() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		s.seatNewPlayers()
+		for !s.closed && (s.game.playersWithChips() < 2 || !s.anyoneSeated()) {
+			s.seated.Wait()
+			s.seatNewPlayers()
+		}
+		if s.closed {
+			return
+		}
+
+		playHand(s.game)
+		s.game.resetRound()
+		s.clearSeats()
+		s.broadcast(s.stateLine())
+
+		s.mu.Unlock()
+		time.Sleep(s.pause)
+		s.mu.Lock()
+	}
+}
+
+// Bring in the players who joined during the last hand
+func (s *server) seatNewPlayers() {
+	for _, p := range s.joining {
+		if p.strategy.(*remoteSeat).left() {
+			continue
+		}
+		s.game.players = append(s.game.players, p)
+		fmt.Fprintf(s.game.out, "%s sits down with %d chips.\n", p.name, p.chips)
+	}
+	s.joining = nil
+}
+
+// Whether anybody connected is sitting at the table. Callers hold mu.
+func (s *server) anyoneSeated() bool {
+	for _, p := range s.game.players {
+		if _, remote := p.strategy.(*remoteSeat); remote {
+			return true
+		}
+	}
+	return false
+}
+
+// clearSeats removes players who left or went broke; bots keep their seats
+// until they are broke too
+func (s *server) clearSeats() {
+	for seat := len(s.game.players) - 1; seat >= 0; seat-- {
+		p := s.game.players[seat]
+		r, remote := p.strategy.(*remoteSeat)
+		switch {
+		case remote && r.left():
+			s.game.removePlayer(seat)
+			fmt.Fprintf(s.game.out, "%s leaves the table.\n", p.name)
+		case p.chips == 0:
+			s.game.removePlayer(seat)
+			if remote {
+				delete(s.sessions, r.token)
+				r.name, r.token = "", ""
+				r.send("BUSTED")
+			}
+		}
+	}
+}
+
+// close stops the engine, the TCP listener and every connection
+func (s *server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.seated.Broadcast()
+	if s.tcp != nil {
+		s.tcp.Close()
+	}
+	s.broadcast("BYE")
+}
+
+// Send a line to every listener and commentator. Callers hold mu.
+func (s *server) broadcast(line string) {
+	for l := range s.listeners {
+		l.send(line)
+	}
+	for l := range s.commentators {
+		l.send(line)
+	}
+}
+
+// stateLine describes the table for a STATE message. Callers hold mu.
+func (s *server) stateLine() string {
+	g := s.game
+	fields := []string{"STATE", g.round, strconv.Itoa(g.pot), strconv.Itoa(g.dealer)}
+	for _, p := range g.players {
+		fields = append(fields, fmt.Sprintf("%s:%d:%d:%s", p.name, p.chips, p.bet, seatStatus(p)))
+	}
+	return strings.Join(fields, " ")
+}
+
+// seatStatus is "in" for a player in the hand, "folded", or "out" when broke
+func seatStatus(p player) string {
+	switch {
+	case p.chips == 0 && p.total == 0:
+		return "out"
+	case p.folded:
+		return "folded"
+	}
+	return "in"
+}
+
+// Whether a name is already taken at the table. Callers hold mu.
+func (s *server) nameTaken(name string) bool {
+	for _, p := range s.game.players {
+		if strings.EqualFold(p.name, name) {
+			return true
+		}
+	}
+	for _, p := range s.joining {
+		if strings.EqualFold(p.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcaster sends the game's play-by-play to the server console and to
+// every connection as INFO lines. The engine writes to it holding mu.
+type broadcaster struct {
+	s   *server
+	buf []byte
+}
+
+func (b *broadcaster) Write(p []byte) (int, error) {
+	b.s.console.Write(p)
+	b.buf = append(b.buf, p...)
+	for {
+		i := strings.IndexByte(string(b.buf), '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(b.buf[:i])); line != "" {
+			b.s.broadcast("INFO " + line)
+		}
+		b.buf = b.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Queue a line for the connection, dropping a client too slow to keep up
+func (c *client) send(line string) {
+	select {
+	case c.out <- line:
+	default:
+		c.conn.Close()
+	}
+}
+
+// writeLoop writes queued lines to the connection and closes it after a BYE
+// or once the client has disconnected
+func (c *client) writeLoop() {
+	defer c.conn.Close()
+	w := bufio.NewWriter(c.conn)
+	for {
+		select {
+		case line := <-c.out:
+			fmt.Fprintln(w, line)
+			if line == "BYE" {
+				w.Flush()
+				return
+			}
+			if len(c.out) == 0 {
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		case <-c.closed:
+			for len(c.out) > 0 {
+				fmt.Fprintln(w, <-c.out)
+			}
+			w.Flush()
+			return
+		}
+	}
+}
+
+// readLoop handles the connection's commands until it disconnects. A player
+// who quits leaves the table; one who just drops keeps their seat for a while.
+func (c *client) readLoop() {
+	c.send("WELCOME five card draw, " + strconv.Itoa(c.s.stack) + " chip stacks. JOIN <name> to sit down, or RESUME <token> to come back")
+
+	quit := false
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		if !c.handle(scanner.Text()) {
+			quit = true
+			break
+		}
+	}
+
+	c.s.mu.Lock()
+	delete(c.s.listeners, c)
+	delete(c.s.commentators, c.commentary)
+	if quit || c.name == "" {
+		c.leave()
+	} else {
+		c.drop()
+	}
+	close(c.closed)
+	c.s.mu.Unlock()
+}
+
+// handle carries out one command, returning false when the client quits
+func (c *client) handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	command := strings.ToUpper(fields[0])
+
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	switch command {
+	case "JOIN":
+		if err := c.join(fields[1:]); err != nil {
+			c.send("ERROR " + err.Error())
+		}
+	case "RESUME":
+		if err := c.resume(fields[1:]); err != nil {
+			c.send("ERROR " + err.Error())
+		}
+	case "FOLD", "CHECK", "CALL", "RAISE", "BET":
+		if err := c.reply("ACTION", line); err != nil {
+			c.send("ERROR " + err.Error())
+		}
+	case "DRAW":
+		if err := c.reply("DRAW", line); err != nil {
+			c.send("ERROR " + err.Error())
+		}
+	case "STATE":
+		c.send(c.s.stateLine())
+	case "ENTROPY":
+		_, entropy, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if err := c.s.game.fair.addEntropy(entropy); err != nil {
+			c.send("ERROR " + err.Error())
+		} else {
+			c.send("INFO Your entropy will be mixed into the next shuffle.")
+		}
+	case "COMMENTATE":
+		if err := c.commentate(); err != nil {
+			c.send("ERROR " + err.Error())
+		}
+	case "QUIT":
+		c.send("BYE")
+		return false
+	default:
+		c.send("ERROR unknown command " + fields[0])
+	}
+	return true
+}
+
+func (c *client) join(args []string) error {
+	if c.commentary != nil {
+		return errors.New("commentators can't play")
+	}
+	if len(args) != 1 {
+		return errors.New("JOIN needs a name of 1-16 letters, digits, - or _")
+	}
+	if err := c.sitDown(args[0]); err != nil {
+		return err
+	}
+	c.detach = c.handOver
+	c.send("SEATED " + c.name + " " + c.token)
+	fmt.Fprintf(c.s.console, "%s joins from %s\n", c.name, c.conn.RemoteAddr())
+	return nil
+}
+
+// resume takes back a seat with its session token, on a new connection
+func (c *client) resume(args []string) error {
+	switch {
+	case c.commentary != nil:
+		return errors.New("commentators can't play")
+	case c.name != "":
+		return errors.New("you are already seated")
+	case len(args) != 1:
+		return errors.New("RESUME needs the token you were given when you sat down")
+	}
+	r := c.s.sessions[args[0]]
+	if r == nil {
+		return errors.New("no seat is kept for that token")
+	}
+	r.reconnect(c.send, c.handOver)
+	c.remoteSeat = r
+	fmt.Fprintf(c.s.console, "%s comes back from %s\n", c.name, c.conn.RemoteAddr())
+
+	// Everything needed to carry on: the seat, the table, the cards and the turn
+	c.send("SEATED " + c.name + " " + c.token)
+	c.send(c.s.stateLine())
+	if seat := c.seat(); seat >= 0 {
+		if p := c.s.game.players[seat]; len(p.hand) > 0 && !p.folded {
+			c.seeHand(c.s.game, seat)
+		}
+	}
+	if name := c.s.turn(); name != "" {
+		c.send("TURN " + name)
+	}
+	if prompt := c.pendingPrompt(); prompt != "" {
+		c.send(prompt)
+	}
+	return nil
+}
+
+// handOver lets go of the seat once another connection resumes it. Callers hold mu.
+func (c *client) handOver() {
+	c.remoteSeat = newRemoteSeat(c.s, c.send)
+	c.send("ERROR your seat was resumed from another connection")
+	c.send("BYE")
+}
+
+// sitDown takes a seat at the next hand under a name. Callers hold mu.
+func (r *remoteSeat) sitDown(name string) error {
+	switch {
+	case r.name != "":
+		return errors.New("you are already seated")
+	case len(r.s.game.players)+len(r.s.joining) >= maxSeats:
+		return errors.New("the table is full")
+	case !validName.MatchString(name):
+		return errors.New("names are 1-16 letters, digits, - or _")
+	case r.s.nameTaken(name):
+		return fmt.Errorf("%s is taken", name)
+	}
+	r.name, r.token = name, newToken()
+	r.s.sessions[r.token] = r
+	r.s.joining = append(r.s.joining, player{name: name, chips: r.s.stack, strategy: r})
+	r.s.seated.Broadcast()
+	return nil
+}
+
+// Seat number of the player at the table, or -1. Callers hold mu.
+func (r *remoteSeat) seat() int {
+	for i, p := range r.s.game.players {
+		if p.strategy == strategy(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Pass a command to the engine if it is waiting for one like it. Callers hold mu.
+func (r *remoteSeat) reply(kind string, line string) error {
+	if r.waiting != kind {
+		return errors.New("it isn't your turn to do that")
+	}
+	r.waiting = ""
+	r.replies <- line
+	return nil
+}
+
+// Whether the player has disconnected or left
+func (r *remoteSeat) left() bool {
+	select {
+	case <-r.gone:
+		return true
+	default:
+		return false
+	}
+}
+
+// Whether the player's connection is down, while their seat is kept
+func (r *remoteSeat) away() bool {
+	select {
+	case <-r.dropped:
+		return true
+	default:
+		return false
+	}
+}
+
+// leave gives up the seat for good. Callers hold mu.
+func (r *remoteSeat) leave() {
+	if r.grace != nil {
+		r.grace.Stop()
+	}
+	delete(r.s.sessions, r.token)
+	if !r.left() {
+		close(r.gone)
+	}
+}
+
+// drop keeps the seat of a player whose connection went down for the grace
+// period, or gives it up straight away with no grace period. Callers hold mu.
+func (r *remoteSeat) drop() {
+	if r.s.grace <= 0 || r.left() {
+		r.leave()
+		return
+	}
+	if r.away() {
+		return
+	}
+	close(r.dropped)
+	r.detach = nil
+	fmt.Fprintf(r.s.game.out, "%s has lost their connection. Their seat is kept for %v.\n", r.name, r.s.grace)
+
+	dropped := r.dropped
+	r.grace = time.AfterFunc(r.s.grace, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		if r.dropped == dropped && !r.left() {
+			fmt.Fprintf(r.s.game.out, "%s didn't come back in time.\n", r.name)
+			r.leave()
+		}
+	})
+}
+
+// reconnect gives the seat a new connection, taking it from the old one if
+// that is still open. Callers hold mu.
+func (r *remoteSeat) reconnect(send func(line string), detach func()) {
+	if r.away() {
+		r.grace.Stop()
+		r.dropped = make(chan struct{})
+		fmt.Fprintf(r.s.game.out, "%s is back.\n", r.name)
+	} else if r.detach != nil {
+		r.detach()
+	}
+	r.send, r.detach = send, detach
+}
+
+// The prompt the engine is waiting on the player to answer, with the time
+// left brought up to date, or "". Callers hold mu.
+func (r *remoteSeat) pendingPrompt() string {
+	if r.waiting == "" {
+		return ""
+	}
+	fields := strings.Fields(r.prompt)
+	seconds := 0
+	if !r.deadline.IsZero() {
+		seconds = int(time.Until(r.deadline).Round(time.Second) / time.Second)
+	}
+	fields[len(fields)-1] = strconv.Itoa(seconds)
+	return strings.Join(fields, " ")
+}
+
+// The name of the remote player the table is waiting on, or "". Callers hold mu.
+func (s *server) turn() string {
+	for _, p := range s.game.players {
+		if r, remote := p.strategy.(*remoteSeat); remote && r.waiting != "" {
+			return p.name
+		}
+	}
+	return ""
+}
+
+var (
+	// errLeft is returned by await when the player leaves while it waits
+	errLeft = errors.New("player left")
+	// errAway is returned by await when the player's connection is down
+	errAway = errors.New("player is away")
+)
+
+// await asks the player for a reply and lets other goroutines use the game
+// until it arrives. The engine calls it holding mu. It gives up if the player
+// leaves or drops their connection, or the context is done.
+func (r *remoteSeat) await(ctx context.Context, kind string, prompt string) (string, error) {
+	r.waiting, r.prompt = kind, prompt
+	r.deadline, _ = ctx.Deadline()
+	dropped := r.dropped
+	if !r.away() {
+		r.send(prompt)
+	}
+
+	r.s.mu.Unlock()
+	var line string
+	var err error
+	select {
+	case line = <-r.replies:
+	case <-r.gone:
+		err = errLeft
+	case <-dropped:
+		err = errAway
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	r.s.mu.Lock()
+
+	if err != nil {
+		// Throw away a reply that raced the clock
+		r.waiting = ""
+		select {
+		case <-r.replies:
+		default:
+		}
+	}
+	return line, err
+}
+
+func (r *remoteSeat) decide(g *game, seat int) decision {
+	d, _ := r.decideWithin(context.Background(), g, seat)
+	return d
+}
+
+func (r *remoteSeat) decideWithin(ctx context.Context, g *game, seat int) (decision, error) {
+	minRaise, maxRaise, err := g.raiseBounds(seat)
+	if err != nil {
+		minRaise, maxRaise = 0, 0
+	}
+	r.send(r.s.stateLine())
+
+	for {
+		prompt := fmt.Sprintf("ACTION %d %d %d %d", g.toCall(seat), minRaise, maxRaise, secondsLeft(ctx))
+		line, err := r.await(ctx, "ACTION", prompt)
+		if err == errLeft {
+			return decision{action: fold}, nil
+		} else if err == errAway && g.toCall(seat) == 0 {
+			fmt.Fprintf(g.out, "%s is away and checks.\n", r.name)
+			return decision{action: call}, nil
+		} else if err == errAway {
+			fmt.Fprintf(g.out, "%s is away and folds.\n", r.name)
+			return decision{action: fold}, nil
+		} else if err != nil {
+			return decision{}, err
+		}
+		d, err := parseAction(line)
+		if err == nil {
+			err = g.validate(seat, d)
+		}
+		if err != nil {
+			r.send("ERROR " + err.Error())
+			continue
+		}
+		return d, nil
+	}
+}
+
+// parseAction reads a FOLD, CHECK, CALL, BET or RAISE command
+func parseAction(line string) (decision, error) {
+	fields := strings.Fields(strings.ToUpper(line))
+	switch fields[0] {
+	case "FOLD":
+		return decision{action: fold}, nil
+	case "CHECK", "CALL":
+		return decision{action: call}, nil
+	}
+	if len(fields) != 2 {
+		return decision{}, fmt.Errorf("%s needs the total to raise to", fields[0])
+	}
+	amount, err := strconv.Atoi(fields[1])
+	if err != nil || amount <= 0 {
+		return decision{}, fmt.Errorf("%q is not a chip amount", fields[1])
+	}
+	return decision{action: raise, amount: amount}, nil
+}
+
+func (r *remoteSeat) discard(g *game, seat int) []int {
+	positions, _ := r.discardWithin(context.Background(), g, seat)
+	return positions
+}
+
+func (r *remoteSeat) discardWithin(ctx context.Context, g *game, seat int) ([]int, error) {
+	line, err := r.await(ctx, "DRAW", fmt.Sprintf("DRAW %d %d", maxDraw, secondsLeft(ctx)))
+	if err == errLeft || err == errAway {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var positions []int
+	for _, field := range strings.Fields(line)[1:] {
+		for _, digit := range field {
+			if digit >= '1' && digit <= '9' {
+				positions = append(positions, int(digit-'1'))
+			}
+		}
+	}
+	return positions, nil
+}
+
+func (r *remoteSeat) seeHand(g *game, seat int) {
+	hand := g.players[seat].hand
+	r.send(fmt.Sprintf("HAND %s: %s", g.evaluate(hand).rankName, hand.toString()))
+}
+
+// runServe is the serve subcommand: it hosts a table for players on the network
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":7777", "address to listen on")
+	bots := flags.Int("bots", 1, "computer players at the table")
+	stack := flags.Int("stack", 1000, "chips for each player who sits down")
+	limit := flags.String("limit", "no-limit", "betting structure: no-limit, pot-limit or fixed-limit")
+	pause := flags.Duration("pause", 3*time.Second, "pause between hands")
+	timeout := flags.Duration("timeout", 30*time.Second, "time to act before checking or folding automatically (0 for no clock)")
+	timeBank := flags.Duration("time-bank", time.Minute, "extra time each player can use over the session")
+	commentaryDelay := flags.Duration("commentary-delay", 0, "let spectators COMMENTATE, seeing every card this long after the play (0 for no commentary)")
+	grace := flags.Duration("grace", time.Minute, "how long a dropped player's seat is kept for them to RESUME (0 to give it up at once)")
+	rake := flags.Float64("rake", 0, "percentage of each pot the house takes")
+	rakeCap := flags.Int("rake-cap", 0, "most the house rakes from one pot (0 for no cap)")
+	noFlopNoDrop := flags.Bool("no-flop-no-drop", true, "don't rake hands that end before the draw")
+	timeFee := flags.Int("time-fee", 0, "chips each player dealt in pays for time at the table")
+	timeEvery := flags.Duration("time-every", 30*time.Minute, "how often the time fee is collected")
+	houseLog := flags.String("house-log", "", "file to append the house report to when the server closes")
+	flags.Parse(args)
+
+	structure, err := parseBettingStructure(*limit)
+	if err != nil {
+		return err
+	}
+	if *bots < 0 || *bots > 7 {
+		return errors.New("bots must be between 0 and 7")
+	}
+	if *rake < 0 || *rake > 100 || *rakeCap < 0 || *timeFee < 0 {
+		return errors.New("the rake must be between 0 and 100 percent, and the cap and time fee can't be negative")
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	g := newGame()
+	g.betting.structure = structure
+	g.timeout = *timeout
+	g.timeBank = *timeBank
+	s := newServer(g, *stack)
+	s.pause = *pause
+	s.commentaryDelay = *commentaryDelay
+	s.grace = *grace
+	s.addBots(*bots)
+	if *rake > 0 || *timeFee > 0 {
+		g.house = newHouse(*rake, *rakeCap, *noFlopNoDrop, *timeFee, *timeEvery)
+	}
+
+	// Ctrl-C closes the table once the hand in play is over, so the house
+	// report can be written
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	go func() {
+		<-interrupted
+		signal.Stop(interrupted)
+		fmt.Println("\nClosing after the hand in play. Ctrl-C again to quit now.")
+		s.close()
+	}()
+
+	fmt.Printf("Poker server listening on %s\n", ln.Addr())
+	go s.listen(ln)
+	s.run()
+	if g.house == nil {
+		return nil
+	}
+	g.house.report(os.Stdout)
+	if *houseLog == "" {
+		return nil
+	}
+	f, err := os.OpenFile(*houseLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	g.house.report(f)
+	fmt.Fprintln(f)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestServer runs a server with one bot on a free local port
+func startTestServer(t *testing.T) (*server, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(newGame(), 1000)
+	s.console = io.Discard
+	s.pause = 0
+	s.addBots(1)
+	go s.listen(ln)
+	go s.run()
+	t.Cleanup(s.close)
+	return s, ln.Addr().String()
+}
+
+// dialTestServer connects and joins under a name
+func dialTestServer(t *testing.T, addr string, name string) (net.Conn, *bufio.Scanner) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	fmt.Fprintf(conn, "JOIN %s\n", name)
+	return conn, bufio.NewScanner(conn)
+}
+
+// expect reads lines until one starts with the prefix
+func expect(t *testing.T, lines *bufio.Scanner, prefix string) string {
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), prefix) {
+			return lines.Text()
+		}
+	}
+	t.Fatalf("Expected a line starting with %q, but the connection ended: %v", prefix, lines.Err())
+	return ""
+}
+
+func TestServerPlaysAHand(t *testing.T) {
+	_, addr := startTestServer(t)
+	conn, lines := dialTestServer(t, addr, "alice")
+	expect(t, lines, "SEATED alice")
+
+	// Call or check every bet and stand pat until the hand is over
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "ACTION"):
+			fmt.Fprintln(conn, "CALL")
+		case strings.HasPrefix(line, "DRAW"):
+			fmt.Fprintln(conn, "DRAW")
+		case strings.HasPrefix(line, "ERROR"):
+			t.Fatalf("Unexpected %v", line)
+		case strings.HasPrefix(line, "INFO Chip counts after hand"):
+			state := expect(t, lines, "STATE")
+			if !strings.Contains(state, "alice:") || !strings.Contains(state, "Computer:") {
+				t.Errorf("Expected both players in the state, but got %v", state)
+			}
+			return
+		}
+	}
+	t.Fatalf("Connection ended before the hand did: %v", lines.Err())
+}
+
+func TestServerRefusesBadCommands(t *testing.T) {
+	_, addr := startTestServer(t)
+	_, first := dialTestServer(t, addr, "bob")
+	expect(t, first, "SEATED bob")
+
+	second, lines := dialTestServer(t, addr, "BOB")
+	if got := expect(t, lines, "ERROR"); !strings.Contains(got, "taken") {
+		t.Errorf("Expected a taken name to be refused, but got %v", got)
+	}
+	fmt.Fprintln(second, "RAISE 500")
+	if got := expect(t, lines, "ERROR"); !strings.Contains(got, "turn") {
+		t.Errorf("Expected an action out of turn to be refused, but got %v", got)
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		line string
+		want decision
+		ok   bool
+	}{
+		{"fold", decision{action: fold}, true},
+		{"CHECK", decision{action: call}, true},
+		{"raise 200", decision{action: raise, amount: 200}, true},
+		{"BET", decision{}, false},
+		{"RAISE lots", decision{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseAction(tt.line)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseAction(%q) = %v, %v", tt.line, got, err)
+		}
+	}
+}
+
+func TestServerTimesOutIdlePlayers(t *testing.T) {
+	s, addr := startTestServer(t)
+	s.mu.Lock()
+	s.game.timeout = 20 * time.Millisecond
+	s.mu.Unlock()
+
+	// Never answer: the clock acts for us
+	_, lines := dialTestServer(t, addr, "idle")
+	if got := expect(t, lines, "INFO idle is out of time"); !strings.Contains(got, "checks") && !strings.Contains(got, "folds") {
+		t.Errorf("Expected the idle player to check or fold, but got %v", got)
+	}
+}
+
+func TestServerKeepsADroppedSeat(t *testing.T) {
+	s, addr := startTestServer(t)
+	s.mu.Lock()
+	s.pause = 50 * time.Millisecond // time for the watcher to keep up with hands played without frank
+	s.mu.Unlock()
+
+	_, watching := watchTestServer(t, addr)
+	conn, lines := dialTestServer(t, addr, "frank")
+	token := strings.Fields(expect(t, lines, "SEATED frank"))[2]
+
+	// Drop the connection while it is frank's turn; the table plays on without him
+	expect(t, lines, "ACTION")
+	conn.Close()
+	expect(t, watching, "INFO frank has lost their connection")
+	if got := expect(t, watching, "INFO frank is away and"); !strings.Contains(got, "checks") && !strings.Contains(got, "folds") {
+		t.Errorf("Expected frank to check or fold while away, but got %v", got)
+	}
+
+	back, lines := watchTestServer(t, addr)
+	fmt.Fprintf(back, "RESUME %s\n", token)
+	if got := expect(t, lines, "SEATED"); got != "SEATED frank "+token {
+		t.Errorf("Expected the seat back under the same token, but got %v", got)
+	}
+	if got := expect(t, lines, "STATE"); !strings.Contains(got, "frank:") {
+		t.Errorf("Expected frank to still have a seat, but got %v", got)
+	}
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "ACTION"):
+			fmt.Fprintln(back, "CALL")
+		case strings.HasPrefix(line, "DRAW"):
+			fmt.Fprintln(back, "DRAW")
+		case strings.HasPrefix(line, "INFO frank is away"):
+			t.Fatalf("Expected frank to act for himself once back, but got %v", line)
+		case strings.HasPrefix(line, "INFO Chip counts after hand"):
+			return
+		}
+	}
+	t.Fatalf("Connection ended before the hand did: %v", lines.Err())
+}
+
+func TestServerGivesUpASeatAfterTheGracePeriod(t *testing.T) {
+	s, addr := startTestServer(t)
+	s.mu.Lock()
+	s.grace = 20 * time.Millisecond
+	s.pause = 50 * time.Millisecond
+	s.mu.Unlock()
+
+	_, watching := watchTestServer(t, addr)
+	conn, lines := dialTestServer(t, addr, "gina")
+	token := strings.Fields(expect(t, lines, "SEATED gina"))[2]
+	conn.Close()
+	expect(t, watching, "INFO gina didn't come back in time")
+
+	back, lines := watchTestServer(t, addr)
+	fmt.Fprintf(back, "RESUME %s\n", token)
+	if got := expect(t, lines, "ERROR"); !strings.Contains(got, "no seat") {
+		t.Errorf("Expected the token to be no good after the grace period, but got %v", got)
+	}
+}
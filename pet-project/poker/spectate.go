@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Spectators follow a table without a seat. Over TCP that's any connection
+// that hasn't joined, and over the API any event feed opened without a token:
+// they get the play-by-play, and see hole cards only when they are shown down.
+//
+// Commentators see every player's cards as they are dealt and drawn, so their
+// feed runs a fixed delay behind the table; by the time they see a hand it is
+// too late to tell anyone playing it.
+
+// timedLine is a message held back until it is due
+type timedLine struct {
+	due  time.Time
+	line string
+}
+
+// laggedListener passes messages on after a delay, in the order they came
+type laggedListener struct {
+	lag   time.Duration
+	lines chan timedLine
+}
+
+// newLaggedListener delivers each message lag after it is sent, until done is closed
+func newLaggedListener(lag time.Duration, deliver func(line string), done <-chan struct{}) *laggedListener {
+	l := &laggedListener{lag: lag, lines: make(chan timedLine, 4096)}
+	go func() {
+		for {
+			select {
+			case next := <-l.lines:
+				select {
+				case <-time.After(time.Until(next.due)):
+					deliver(next.line)
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return l
+}
+
+// send holds a message back; one that falls too far behind is dropped
+func (l *laggedListener) send(line string) {
+	select {
+	case l.lines <- timedLine{time.Now().Add(l.lag), line}:
+	default:
+	}
+}
+
+// seeHand tells commentators about a player's cards. The server watches the
+// game for this. Callers hold mu.
+func (s *server) seeHand(g *game, seat int) {
+	p := g.players[seat]
+	line := fmt.Sprintf("CARDS %s %s: %s", p.name, g.evaluate(p.hand).rankName, p.hand.toString())
+	for c := range s.commentators {
+		c.send(line)
+	}
+}
+
+// commentate moves a spectator over to the delayed feed with every player's
+// cards. Callers hold mu.
+func (c *client) commentate() error {
+	switch {
+	case c.s.commentaryDelay <= 0:
+		return fmt.Errorf("commentary is off at this table")
+	case c.name != "":
+		return fmt.Errorf("players can't commentate")
+	case c.commentary != nil:
+		return fmt.Errorf("you are already commentating")
+	}
+	c.commentary = newLaggedListener(c.s.commentaryDelay, c.send, c.closed)
+	delete(c.s.listeners, c)
+	c.s.commentators[c.commentary] = true
+	c.send(fmt.Sprintf("COMMENTATING %d", int(c.s.commentaryDelay/time.Second)))
+	return nil
+}
+
+// spectate hands the human's seat to a bot, so the console only watches.
+// The players are numbered again as they would be in an all-computer game.
+func (g *game) spectate() {
+	g.players[0].strategy = newAdaptiveBot()
+	for i := range g.players {
+		g.players[i].name = fmt.Sprintf("Computer %d", i+1)
+	}
+}
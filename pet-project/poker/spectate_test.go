@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLaggedListenerKeepsOrder(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	got := make(chan string, 3)
+	l := newLaggedListener(20*time.Millisecond, func(line string) { got <- line }, done)
+
+	sent := time.Now()
+	for _, line := range []string{"one", "two", "three"} {
+		l.send(line)
+	}
+	for _, want := range []string{"one", "two", "three"} {
+		if line := <-got; line != want {
+			t.Errorf("Expected %q next, but got %q", want, line)
+		}
+	}
+	if took := time.Since(sent); took < 20*time.Millisecond {
+		t.Errorf("Expected the lines to be held back, but they came after %v", took)
+	}
+}
+
+// watchTestServer connects without joining
+func watchTestServer(t *testing.T, addr string) (net.Conn, *bufio.Scanner) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return conn, bufio.NewScanner(conn)
+}
+
+func TestSpectatorsSeeNoHoleCards(t *testing.T) {
+	_, addr := startTestServer(t)
+	_, watching := watchTestServer(t, addr)
+	expect(t, watching, "WELCOME")
+
+	player, lines := dialTestServer(t, addr, "carol")
+	expect(t, lines, "SEATED carol")
+	go func() {
+		for lines.Scan() {
+			if strings.HasPrefix(lines.Text(), "ACTION") {
+				fmt.Fprintln(player, "FOLD")
+			}
+		}
+	}()
+
+	for watching.Scan() {
+		line := watching.Text()
+		if strings.HasPrefix(line, "HAND") || strings.HasPrefix(line, "CARDS") {
+			t.Fatalf("Expected a spectator not to see hole cards, but got %v", line)
+		}
+		if strings.HasPrefix(line, "INFO Chip counts after hand") {
+			return
+		}
+	}
+	t.Fatalf("Connection ended before the hand did: %v", watching.Err())
+}
+
+func TestCommentatorSeesEveryHand(t *testing.T) {
+	s, addr := startTestServer(t)
+	conn, watching := watchTestServer(t, addr)
+	fmt.Fprintln(conn, "COMMENTATE")
+	if got := expect(t, watching, "ERROR"); !strings.Contains(got, "off") {
+		t.Errorf("Expected commentary to be off by default, but got %v", got)
+	}
+
+	s.mu.Lock()
+	s.commentaryDelay = 10 * time.Millisecond
+	s.mu.Unlock()
+	fmt.Fprintln(conn, "COMMENTATE")
+	expect(t, watching, "COMMENTATING")
+	fmt.Fprintln(conn, "JOIN dave")
+	if got := expect(t, watching, "ERROR"); !strings.Contains(got, "commentators") {
+		t.Errorf("Expected a commentator to be kept from playing, but got %v", got)
+	}
+
+	player, lines := dialTestServer(t, addr, "erin")
+	expect(t, lines, "SEATED erin")
+	go func() {
+		for lines.Scan() {
+			if strings.HasPrefix(lines.Text(), "ACTION") {
+				fmt.Fprintln(player, "CALL")
+			}
+		}
+	}()
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		line := expect(t, watching, "CARDS")
+		name, _, _ := strings.Cut(strings.TrimPrefix(line, "CARDS "), " ")
+		seen[name] = true
+	}
+	if !seen["erin"] || !seen["Computer"] {
+		t.Errorf("Expected to see both players' cards, but saw %v", seen)
+	}
+}
+
+func TestSpectatedGameEndsWithOneStack(t *testing.T) {
+	g := newGame()
+	g.spectate()
+	if g.humanSeat() >= 0 {
+		t.Fatalf("Expected no human seat while spectating")
+	}
+	if g.isGameOver() {
+		t.Errorf("Expected the game to go on while several players have chips")
+	}
+	for i := 1; i < len(g.players); i++ {
+		g.players[i].chips = 0
+	}
+	if !g.isGameOver() {
+		t.Errorf("Expected the game to be over with one stack left")
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Profiles keep a player's bankroll and lifetime numbers from one run of the
+// game to the next, in a JSON file in the home directory. Alongside the chips
+// they count the usual HUD stats:
+//
+//	VPIP   hands where they put chips in before the draw of their own accord
+//	PFR    hands where they raised before the draw
+//	3-bet  how often they re-raised a single raise before the draw
+//	AF     aggression factor: bets and raises for every call
+//	WTSD   went to showdown, of the hands where they saw the draw
+
+// A new profile's bankroll, and what a broke one is topped back up to
+const startingBankroll = 1000
+
+// playerStats are the counts the HUD stats are worked out from
+type playerStats struct {
+	Hands           int `json:"hands"`
+	VPIP            int `json:"vpip"`            // hands with a call or raise before the draw
+	PFR             int `json:"pfr"`             // hands with a raise before the draw
+	ThreeBetChances int `json:"threeBetChances"` // hands facing a single raise before the draw
+	ThreeBets       int `json:"threeBets"`
+	Aggressive      int `json:"aggressive"` // bets and raises
+	Calls           int `json:"calls"`      // calls of a bet, not checks
+	SawDraw         int `json:"sawDraw"`
+	Showdowns       int `json:"showdowns"`
+}
+
+// profile is a player's record over every session they've played
+type profile struct {
+	Name       string      `json:"name"`
+	Bankroll   int         `json:"bankroll"`
+	Net        int         `json:"net"` // chips won or lost over every session
+	BiggestPot int         `json:"biggestPot"`
+	Sessions   int         `json:"sessions"`
+	Rebuys     int         `json:"rebuys"` // times the bankroll went broke and was topped up
+	Stats      playerStats `json:"stats"`
+}
+
+// profileStore is the file the profiles are kept in
+type profileStore struct {
+	path     string
+	profiles map[string]*profile
+}
+
+// Where profiles are kept unless told otherwise
+func defaultProfilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "poker-profiles.json"
+	}
+	return filepath.Join(home, ".poker", "profiles.json")
+}
+
+// loadProfiles reads the store, which is empty if the file doesn't exist yet
+func loadProfiles(path string) (*profileStore, error) {
+	store := &profileStore{path: path, profiles: make(map[string]*profile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.profiles); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return store, nil
+}
+
+// save writes the store out whole, through a temporary file so a crash
+// can't leave it half written
+func (store *profileStore) save() error {
+	data, err := json.MarshalIndent(store.profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
+
+// get finds a profile, making a new one with a starting bankroll if needed
+func (store *profileStore) get(name string) *profile {
+	p, ok := store.profiles[name]
+	if !ok {
+		p = &profile{Name: name, Bankroll: startingBankroll}
+		store.profiles[name] = p
+	}
+	return p
+}
+
+// handFlags are what a player did in the hand being played
+type handFlags struct {
+	vpip, pfr, threeBetChance, threeBet bool
+	foldedAfterDraw                     bool
+}
+
+// statsKeeper counts the stats of the players who have profiles. The tables
+// of a tournament share it, and play their hands at the same time.
+type statsKeeper struct {
+	mu       sync.Mutex
+	store    *profileStore
+	players  map[string]*profile // profiles by the name played under
+	bankroll bool                // chips won and lost count toward the bankroll
+	hand     map[string]*handFlags
+	start    map[string]int // chips at the start of the hand
+}
+
+func newStatsKeeper(store *profileStore) *statsKeeper {
+	return &statsKeeper{store: store, players: make(map[string]*profile), bankroll: true}
+}
+
+// startHand notes everyone's chips before the blinds go in. It does nothing
+// without a keeper, as do the other hooks.
+func (k *statsKeeper) startHand(g *game) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.hand == nil {
+		k.hand = make(map[string]*handFlags)
+		k.start = make(map[string]int)
+	}
+	for _, p := range g.players {
+		if _, ok := k.players[p.name]; ok {
+			k.hand[p.name] = &handFlags{}
+			k.start[p.name] = p.chips
+		}
+	}
+}
+
+// action counts a decision, before it is applied to the table
+func (k *statsKeeper) action(g *game, seat int, d decision) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	name := g.players[seat].name
+	prof, flags := k.players[name], k.hand[name]
+	if prof == nil || flags == nil {
+		return
+	}
+
+	facing := g.toCall(seat) > 0
+	switch d.action {
+	case raise:
+		prof.Stats.Aggressive++
+	case call:
+		if facing {
+			prof.Stats.Calls++
+		}
+	}
+
+	if g.round == preDraw {
+		// The big blind counts as the first bet, so a single raise makes two
+		if g.raises == 2 {
+			flags.threeBetChance = true
+			flags.threeBet = flags.threeBet || d.action == raise
+		}
+		flags.pfr = flags.pfr || d.action == raise
+		flags.vpip = flags.vpip || d.action == raise || (d.action == call && facing)
+	} else if d.action == fold {
+		flags.foldedAfterDraw = true
+	}
+}
+
+// endHand adds up the hand once the pot has been paid out, and saves the profiles
+func (k *statsKeeper) endHand(g *game, showdown bool) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	counted := false
+	for _, p := range g.players {
+		prof, flags := k.players[p.name], k.hand[p.name]
+		if prof == nil || flags == nil || len(p.hand) == 0 {
+			continue
+		}
+		delete(k.hand, p.name)
+		counted = true
+		s := &prof.Stats
+		s.Hands++
+		if flags.vpip {
+			s.VPIP++
+		}
+		if flags.pfr {
+			s.PFR++
+		}
+		if flags.threeBetChance {
+			s.ThreeBetChances++
+		}
+		if flags.threeBet {
+			s.ThreeBets++
+		}
+		if g.round == postDraw && (!p.folded || flags.foldedAfterDraw) {
+			s.SawDraw++
+			if showdown && !p.folded {
+				s.Showdowns++
+			}
+		}
+
+		if !k.bankroll {
+			continue
+		}
+		net := p.chips - k.start[p.name]
+		prof.Net += net
+		prof.Bankroll += net
+		if pot := g.ledger.potSize(); net > 0 && pot > prof.BiggestPot {
+			prof.BiggestPot = pot
+		}
+	}
+	if !counted {
+		return
+	}
+	if err := k.store.save(); err != nil {
+		fmt.Fprintf(g.out, "Couldn't save the player profiles: %v\n", err)
+	}
+}
+
+// Percentage of a count, or "-" when there was no chance to count it
+func percentOf(n, of int) string {
+	if of == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(n)/float64(of))
+}
+
+func (s playerStats) vpip() string     { return percentOf(s.VPIP, s.Hands) }
+func (s playerStats) pfr() string      { return percentOf(s.PFR, s.Hands) }
+func (s playerStats) threeBet() string { return percentOf(s.ThreeBets, s.ThreeBetChances) }
+func (s playerStats) wtsd() string     { return percentOf(s.Showdowns, s.SawDraw) }
+
+func (s playerStats) aggression() string {
+	if s.Calls == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(s.Aggressive)/float64(s.Calls))
+}
+
+// runStats is the stats subcommand: it lists every profile, or shows one in full
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	path := flags.String("profiles", defaultProfilePath(), "file the player profiles are kept in")
+	flags.Parse(args)
+
+	store, err := loadProfiles(*path)
+	if err != nil {
+		return err
+	}
+
+	if name := flags.Arg(0); name != "" {
+		p, ok := store.profiles[name]
+		if !ok {
+			return fmt.Errorf("no profile called %q in %s", name, *path)
+		}
+		fmt.Printf("Profile: %s\n", p.Name)
+		fmt.Printf("Bankroll: %d (net %+d, topped up %d times)\n", p.Bankroll, p.Net, p.Rebuys)
+		fmt.Printf("Sessions: %d\n", p.Sessions)
+		fmt.Printf("Hands: %d\n", p.Stats.Hands)
+		fmt.Printf("Biggest pot won: %d\n", p.BiggestPot)
+		fmt.Printf("VPIP: %s\n", p.Stats.vpip())
+		fmt.Printf("PFR: %s\n", p.Stats.pfr())
+		fmt.Printf("3-bet: %s\n", p.Stats.threeBet())
+		fmt.Printf("Aggression factor: %s\n", p.Stats.aggression())
+		fmt.Printf("Went to showdown: %s\n", p.Stats.wtsd())
+		return nil
+	}
+
+	if len(store.profiles) == 0 {
+		fmt.Printf("No profiles in %s yet. Play with -profile <name> to start one.\n", *path)
+		return nil
+	}
+	names := make([]string, 0, len(store.profiles))
+	for name := range store.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Printf("%-12s %8s %6s %8s %9s %7s %5s %5s %6s %4s %5s\n", "Player", "Sessions", "Hands", "Net", "Bankroll", "Biggest", "VPIP", "PFR", "3-bet", "AF", "WTSD")
+	for _, name := range names {
+		p := store.profiles[name]
+		fmt.Printf("%-12s %8d %6d %+8d %9d %7d %5s %5s %6s %4s %5s\n", p.Name, p.Sessions, p.Stats.Hands, p.Net, p.Bankroll, p.BiggestPot,
+			p.Stats.vpip(), p.Stats.pfr(), p.Stats.threeBet(), p.Stats.aggression(), p.Stats.wtsd())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfilesCountEveryHand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	store, err := loadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newGame()
+	g.out = io.Discard
+	g.stats = newStatsKeeper(store)
+	g.stats.players["You"] = store.get("alice")
+	g.stats.players["Computer"] = store.get("bot")
+
+	// You open, the computer 3-bets and you call, then both check it down
+	g.players[0].strategy = &scripted{decisions: []decision{{action: raise, amount: 150}, {action: call}, {action: call}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 400}, {action: call}}}
+	playHand(g)
+	g.resetRound()
+
+	// Then you fold your big blind to a raise, a chance to 3-bet you pass up
+	g.players[0].strategy = &scripted{decisions: []decision{{action: fold}}}
+	g.players[1].strategy = &scripted{decisions: []decision{{action: raise, amount: 200}}}
+	playHand(g)
+
+	store, err = loadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bot := store.profiles["alice"], store.profiles["bot"]
+	if alice == nil || bot == nil {
+		t.Fatalf("Expected both profiles to be saved, but got %v", store.profiles)
+	}
+	want := playerStats{Hands: 2, VPIP: 1, PFR: 1, ThreeBetChances: 1, Aggressive: 1, Calls: 1, SawDraw: 1, Showdowns: 1}
+	if alice.Stats != want {
+		t.Errorf("Expected alice's stats to be %+v, but got %+v", want, alice.Stats)
+	}
+	want = playerStats{Hands: 2, VPIP: 2, PFR: 2, ThreeBetChances: 1, ThreeBets: 1, Aggressive: 2, SawDraw: 1, Showdowns: 1}
+	if bot.Stats != want {
+		t.Errorf("Expected the bot's stats to be %+v, but got %+v", want, bot.Stats)
+	}
+	if alice.Net+bot.Net != 0 || alice.Bankroll != startingBankroll+alice.Net || alice.Net != g.players[0].chips-1000 {
+		t.Errorf("Expected the chips to carry to the bankrolls, but alice is %+d to %d and the bot %+d", alice.Net, alice.Bankroll, bot.Net)
+	}
+	winner := alice
+	if bot.Net > alice.Net {
+		winner = bot
+	}
+	if winner.BiggestPot != 800 {
+		t.Errorf("Expected the 3-bet pot of 800 to be the biggest won, but got %d", winner.BiggestPot)
+	}
+	if got := alice.Stats.aggression(); got != "1.0" {
+		t.Errorf("Expected an aggression factor of 1.0, but got %s", got)
+	}
+
+	if err := runStats([]string{"-profiles", path}); err != nil {
+		t.Error(err)
+	}
+	if err := runStats([]string{"-profiles", path, "carol"}); err == nil {
+		t.Errorf("Expected an error for a profile that doesn't exist")
+	}
+}
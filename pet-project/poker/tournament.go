@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blindLevel is one step of a tournament's blind schedule
+type blindLevel struct {
+	smallBlind int
+	bigBlind   int
+	ante       int
+}
+
+func (l blindLevel) String() string {
+	if l.ante > 0 {
+		return fmt.Sprintf("%d/%d ante %d", l.smallBlind, l.bigBlind, l.ante)
+	}
+	return fmt.Sprintf("%d/%d", l.smallBlind, l.bigBlind)
+}
+
+// defaultSchedule is a blind schedule for 1500 chip starting stacks
+func defaultSchedule() []blindLevel {
+	return []blindLevel{
+		{25, 50, 0}, {50, 100, 0}, {75, 150, 0}, {100, 200, 25},
+		{150, 300, 25}, {200, 400, 50}, {300, 600, 75}, {400, 800, 100},
+		{500, 1000, 100}, {750, 1500, 200}, {1000, 2000, 300},
+	}
+}
+
+// parseSchedule reads levels written as "small/big" or "small/big/ante",
+// separated by commas, such as "25/50,50/100,100/200/25"
+func parseSchedule(text string) ([]blindLevel, error) {
+	var levels []blindLevel
+	for _, part := range strings.Split(text, ",") {
+		fields := strings.Split(strings.TrimSpace(part), "/")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("blind level %q should be small/big or small/big/ante", part)
+		}
+		numbers := make([]int, 3)
+		for i, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("blind level %q: %q is not a chip amount", part, field)
+			}
+			numbers[i] = n
+		}
+		if numbers[0] > numbers[1] {
+			return nil, fmt.Errorf("blind level %q: small blind is bigger than the big blind", part)
+		}
+		levels = append(levels, blindLevel{numbers[0], numbers[1], numbers[2]})
+	}
+	return levels, nil
+}
+
+// defaultPayouts pays the winner alone at small tables and more places as the field grows
+func defaultPayouts(players int) []int {
+	switch {
+	case players <= 4:
+		return []int{100}
+	case players <= 6:
+		return []int{65, 35}
+	}
+	return []int{50, 30, 20}
+}
+
+// parsePayouts reads the percentage of the prize pool paid to each place,
+// such as "50,30,20". The percentages have to add up to 100.
+func parsePayouts(text string) ([]int, error) {
+	var payouts []int
+	total := 0
+	for _, part := range strings.Split(text, ",") {
+		pct, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || pct < 0 {
+			return nil, fmt.Errorf("payout %q is not a percentage", part)
+		}
+		payouts = append(payouts, pct)
+		total += pct
+	}
+	if total != 100 {
+		return nil, errors.New("payouts must add up to 100%")
+	}
+	return payouts, nil
+}
+
+// finish is where a player placed in a tournament
+type finish struct {
+	name  string
+	place int
+	hand  int // hand number they were knocked out on, 0 for the winner
+	prize int
+}
+
+// tournament runs one or more tables as a freezeout: everyone starts with the
+// same stack, blinds go up on a schedule and play continues until one player
+// has all the chips
+type tournament struct {
+	tables        []*game
+	entrants      int
+	seats         int // most players at a table
+	finalTable    bool
+	schedule      []blindLevel
+	level         int
+	handsPerLevel int           // raise the blinds after this many hands, or
+	levelDuration time.Duration // after this long when handsPerLevel is 0
+	levelStarted  time.Time
+	levelHands    int
+	hands         int
+	buyIn         int
+	payouts       []int // percentage of the prize pool for 1st, 2nd, ...
+	finishes      []finish
+	startChips    map[string]int // stacks at the start of the current hand
+	clock         func() time.Time
+	out           io.Writer // where rising blinds and knockouts are announced
+}
+
+func newTournament(tables []*game, stack int, schedule []blindLevel, buyIn int, payouts []int) *tournament {
+	entrants := 0
+	for _, g := range tables {
+		for i := range g.players {
+			g.players[i].chips = stack
+			entrants++
+		}
+	}
+	t := &tournament{
+		tables:        tables,
+		entrants:      entrants,
+		seats:         8,
+		schedule:      schedule,
+		handsPerLevel: 10,
+		buyIn:         buyIn,
+		payouts:       payouts,
+		clock:         time.Now,
+		out:           os.Stdout,
+	}
+	t.setLevel(0)
+	return t
+}
+
+// Move the game's blinds and ante to a level of the schedule
+func (t *tournament) setLevel(level int) {
+	t.level = level
+	t.levelHands = 0
+	t.levelStarted = t.clock()
+	current := t.schedule[level]
+	for _, g := range t.tables {
+		g.smallBlind = current.smallBlind
+		g.bigBlind = current.bigBlind
+		g.ante = current.ante
+	}
+}
+
+// levelDue reports whether the current level has run its course
+func (t *tournament) levelDue() bool {
+	if t.level == len(t.schedule)-1 {
+		return false // Stay on the last level
+	}
+	if t.handsPerLevel > 0 {
+		return t.levelHands >= t.handsPerLevel
+	}
+	return t.clock().Sub(t.levelStarted) >= t.levelDuration
+}
+
+// startHand raises the blinds if the level is up and remembers every stack,
+// so players knocked out in the same hand can be placed by chip count
+func (t *tournament) startHand() {
+	if t.levelDue() {
+		t.setLevel(t.level + 1)
+		fmt.Fprintf(t.out, "\n*** Blinds go up to %s (level %d) ***\n", t.schedule[t.level], t.level+1)
+	}
+	t.hands++
+	t.levelHands++
+
+	t.startChips = make(map[string]int)
+	for _, g := range t.tables {
+		for _, p := range g.players {
+			t.startChips[p.name] = p.chips
+		}
+	}
+}
+
+// recordEliminations places everyone who went broke this hand. Players out in
+// the same hand finish in order of the stacks they started it with.
+func (t *tournament) recordEliminations() {
+	remaining := t.playersLeft()
+	var busted []string
+	for _, g := range t.tables {
+		for _, p := range g.players {
+			if p.chips == 0 && t.startChips[p.name] > 0 {
+				busted = append(busted, p.name)
+			}
+		}
+	}
+
+	// Smallest starting stack finishes lowest
+	for len(busted) > 0 {
+		lowest := 0
+		for j, name := range busted {
+			if t.startChips[name] < t.startChips[busted[lowest]] {
+				lowest = j
+			}
+		}
+		name := busted[lowest]
+		busted = append(busted[:lowest], busted[lowest+1:]...)
+
+		place := remaining + len(busted) + 1
+		t.finishes = append(t.finishes, finish{name: name, place: place, hand: t.hands, prize: t.prize(place)})
+		fmt.Fprintf(t.out, "%s is knocked out in %s place.\n", name, ordinal(place))
+	}
+
+	if t.isOver() {
+		for _, g := range t.tables {
+			for _, p := range g.players {
+				if p.chips > 0 {
+					t.finishes = append(t.finishes, finish{name: p.name, place: 1, prize: t.prize(1)})
+				}
+			}
+		}
+	}
+}
+
+// Number of players with chips across every table
+func (t *tournament) playersLeft() int {
+	count := 0
+	for _, g := range t.tables {
+		count += g.playersWithChips()
+	}
+	return count
+}
+
+// The tournament is over once one player has all the chips
+func (t *tournament) isOver() bool {
+	return t.playersLeft() <= 1
+}
+
+// Prize for a finishing place; the first place also gets any rounding leftovers
+func (t *tournament) prize(place int) int {
+	if place > len(t.payouts) {
+		return 0
+	}
+	pool := t.buyIn * t.entrants
+	amount := pool * t.payouts[place-1] / 100
+	if place == 1 {
+		paid := 0
+		for _, pct := range t.payouts {
+			paid += pool * pct / 100
+		}
+		amount += pool - paid
+	}
+	return amount
+}
+
+// Place a player finished in, or 0 while they are still playing
+func (t *tournament) placeOf(name string) int {
+	for _, f := range t.finishes {
+		if f.name == name {
+			return f.place
+		}
+	}
+	return 0
+}
+
+// showStandings prints the finishing places from first to last
+func (t *tournament) showStandings() {
+	fmt.Println("\n=== TOURNAMENT RESULTS ===")
+	fmt.Printf("Prize pool: %d\n", t.buyIn*t.entrants)
+	for place := 1; place <= t.entrants; place++ {
+		for _, f := range t.finishes {
+			if f.place != place {
+				continue
+			}
+			line := fmt.Sprintf("%s: %s", ordinal(place), f.name)
+			if f.hand > 0 {
+				line += fmt.Sprintf(" (out on hand %d)", f.hand)
+			}
+			if f.prize > 0 {
+				line += fmt.Sprintf(" wins %d", f.prize)
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// ordinal turns 1 into "1st", 2 into "2nd" and so on
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
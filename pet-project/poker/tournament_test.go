@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTournamentBlindsGoUp(t *testing.T) {
+	g := newGame()
+	tour := newTournament([]*game{g}, 1500, defaultSchedule(), 100, []int{100})
+	tour.handsPerLevel = 2
+
+	for i := 0; i < 3; i++ {
+		tour.startHand()
+	}
+
+	if tour.level != 1 || g.bigBlind != 100 {
+		t.Errorf("Expected level 2 with a 100 big blind after 2 hands, but got level %v with %v", tour.level+1, g.bigBlind)
+	}
+}
+
+func TestTournamentTimedLevels(t *testing.T) {
+	now := time.Now()
+	g := newGame()
+	tour := newTournament([]*game{g}, 1500, defaultSchedule(), 100, []int{100})
+	tour.clock = func() time.Time { return now }
+	tour.handsPerLevel = 0
+	tour.levelDuration = 10 * time.Minute
+
+	tour.startHand()
+	now = now.Add(11 * time.Minute)
+	tour.startHand()
+
+	if tour.level != 1 {
+		t.Errorf("Expected the blinds to go up after 10 minutes, but got level %v", tour.level+1)
+	}
+}
+
+func TestTournamentPlacesSameHandBustsByStack(t *testing.T) {
+	g := newGame()
+	g.addOpponents(3)
+	tour := newTournament([]*game{g}, 1500, defaultSchedule(), 100, []int{70, 30})
+	tour.startHand()
+	tour.startChips = map[string]int{"You": 1500, "Computer 1": 500, "Computer 2": 800, "Computer 3": 3200}
+
+	// Computers 1 and 2 both bust in the same hand
+	g.players[1].chips = 0
+	g.players[2].chips = 0
+	g.players[3].chips = 4500
+	tour.recordEliminations()
+
+	if place := tour.placeOf("Computer 1"); place != 4 {
+		t.Errorf("Expected the smaller stack to finish 4th, but got %v", place)
+	}
+	if place := tour.placeOf("Computer 2"); place != 3 {
+		t.Errorf("Expected the bigger stack to finish 3rd, but got %v", place)
+	}
+	if tour.prize(1)+tour.prize(2) != 400 {
+		t.Errorf("Expected the whole prize pool of 400 to be paid, but got %v", tour.prize(1)+tour.prize(2))
+	}
+}
+
+func TestParsePayoutsMustAddUp(t *testing.T) {
+	if _, err := parsePayouts("50,30"); err == nil {
+		t.Errorf("Expected payouts adding up to 80%% to be rejected")
+	}
+	if _, err := parseSchedule("25/50,50/100/10"); err != nil {
+		t.Errorf("Expected a valid schedule, but got %v", err)
+	}
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The full-screen table draws the seats around a felt with the cards as
+// glyphs, keeps the play-by-play underneath, and gives the player buttons and
+// a bet slider in place of the numbered menu. It needs nothing but a terminal
+// that understands ANSI escapes, which it puts into cbreak mode with stty so
+// every key arrives as it is pressed.
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiFelt    = "\x1b[32m"
+	ansiRed     = "\x1b[31;47m" // hearts and diamonds on a white card
+	ansiBlack   = "\x1b[30;47m" // spades and clubs
+	ansiJoker   = "\x1b[35;47m"
+	ansiBack    = "\x1b[37;44m" // the back of a card
+	ansiButton  = "\x1b[30;43m" // the dealer button
+)
+
+var (
+	rankGlyphs = map[string]string{
+		"Two": "2", "Three": "3", "Four": "4", "Five": "5", "Six": "6", "Seven": "7", "Eight": "8",
+		"Nine": "9", "Ten": "10", "Jack": "J", "Queen": "Q", "King": "K", "Ace": "A",
+	}
+	suitGlyphs = map[string]string{"Spades": "♠", "Hearts": "♥", "Diamonds": "♦", "Clubs": "♣"}
+)
+
+// cardGlyph draws a card face up, such as a red "10♥"
+func cardGlyph(c string) string {
+	if c == jokerCard {
+		return ansiJoker + "Jk★" + ansiReset
+	}
+	value, suit, _ := strings.Cut(c, " of ")
+	colour := ansiBlack
+	if suit == "Hearts" || suit == "Diamonds" {
+		colour = ansiRed
+	}
+	return colour + fmt.Sprintf("%2s%s", rankGlyphs[value], suitGlyphs[suit]) + ansiReset
+}
+
+// Draw a card face down
+const cardBack = ansiBack + "▒▒▒" + ansiReset
+
+// Keys other than printable characters, by the escape sequence terminals send
+var escapeKeys = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1bOH": "home", "\x1b[1~": "home", "\x1b[7~": "home",
+	"\x1b[F": "end", "\x1bOF": "end", "\x1b[4~": "end", "\x1b[8~": "end",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdn",
+}
+
+// scanKeys splits terminal input into keys: a character as typed, or the name
+// of a special key such as "up" or "enter". Unknown escape sequences come out
+// as empty keys.
+func scanKeys(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	if data[0] == 0x1b && len(data) > 1 && (data[1] == '[' || data[1] == 'O') {
+		for i := 2; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				return i + 1, []byte(escapeKeys[string(data[:i+1])]), nil
+			}
+		}
+		if !atEOF {
+			return 0, nil, nil
+		}
+		return len(data), []byte{}, nil
+	}
+
+	if !utf8.FullRune(data) && !atEOF {
+		return 0, nil, nil
+	}
+	r, size := utf8.DecodeRune(data)
+	switch r {
+	case '\r', '\n':
+		return size, []byte("enter"), nil
+	case 0x7f, '\b':
+		return size, []byte("backspace"), nil
+	case '\t':
+		return size, []byte("tab"), nil
+	case 0x04: // Ctrl-D
+		return size, []byte("eof"), nil
+	case 0x1b:
+		return size, []byte("escape"), nil
+	}
+	return size, data[:size], nil
+}
+
+// tui draws a game full screen. Everything the game writes goes to its log.
+type tui struct {
+	g      *game
+	term   io.Writer   // the terminal
+	keys   *lineSource // single keys, not lines
+	width  int
+	height int
+	saved  string // stty settings to put back
+
+	log     []string // the play-by-play, a line at a time
+	partial string   // written since the last newline, such as a prompt
+	bar     []string // the bottom of the screen: buttons, or the line being typed
+	editing bool     // a line is being typed, so the cursor is shown
+	turn    int      // the seat choosing an action, -1 between turns
+	stopped sync.Once
+}
+
+// Lines of the play-by-play kept for the log
+const maxLogLines = 500
+
+func newTUI(g *game, term io.Writer, keys io.Reader) *tui {
+	return &tui{g: g, term: term, keys: newTokenSource(keys, scanKeys), width: 80, height: 24, turn: -1}
+}
+
+// Run stty on the terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// startTUI takes over the terminal for a game, until stop gives it back. The
+// console input reads its lines through the table from then on, so it has to
+// start before anything reads the keyboard.
+func startTUI(g *game) (*tui, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("the full-screen table needs a terminal (%v)", err)
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("can't read single keys from the terminal (%v)", err)
+	}
+
+	t := newTUI(g, os.Stdout, os.Stdin)
+	t.saved = saved
+	if size, err := stty("size"); err == nil {
+		fmt.Sscan(size, &t.height, &t.width)
+	}
+	stdinOnce.Do(func() {
+		stdinLines = &lineSource{edit: t.readLine}
+	})
+
+	// Give the terminal back on Ctrl-C too
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	go func() {
+		<-interrupted
+		t.stop()
+		os.Exit(130)
+	}()
+
+	io.WriteString(t.term, "\x1b[?1049h") // The alternate screen
+	t.draw()
+	return t, nil
+}
+
+// stop puts the terminal back the way it was. It does nothing without a
+// full-screen table, or the second time.
+func (t *tui) stop() {
+	if t == nil {
+		return
+	}
+	t.stopped.Do(func() {
+		io.WriteString(t.term, "\x1b[?25h\x1b[?1049l")
+		if t.saved != "" {
+			stty(t.saved)
+		}
+	})
+}
+
+// Write adds the game's output to the log and redraws the screen
+func (t *tui) Write(p []byte) (int, error) {
+	lines := strings.Split(t.partial+string(p), "\n")
+	t.partial = lines[len(lines)-1]
+	t.log = append(t.log, lines[:len(lines)-1]...)
+	if len(t.log) > maxLogLines {
+		t.log = t.log[len(t.log)-maxLogLines:]
+	}
+	t.draw()
+	return len(p), nil
+}
+
+// Finish the line being typed, keeping it in the log as a terminal would
+func (t *tui) endLine(line string) {
+	t.log = append(t.log, line)
+	t.bar, t.editing = nil, false
+}
+
+// readLine lets the player type a line at the bottom of the screen, after the
+// prompt the game last wrote
+func (t *tui) readLine(ctx context.Context) (string, error) {
+	prompt := t.partial
+	t.partial = ""
+	var typed []rune
+	for {
+		t.bar, t.editing = []string{"", prompt + string(typed)}, true
+		t.draw()
+
+		key, err := t.keys.readLine(ctx)
+		if err != nil {
+			t.endLine(prompt + string(typed))
+			return "", err
+		}
+		switch key {
+		case "enter":
+			t.endLine(prompt + string(typed))
+			return string(typed), nil
+		case "backspace":
+			if len(typed) > 0 {
+				typed = typed[:len(typed)-1]
+			}
+		case "eof":
+			if len(typed) == 0 {
+				t.endLine(prompt)
+				return "", io.EOF
+			}
+		default:
+			if r, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(r) {
+				typed = append(typed, r)
+			}
+		}
+	}
+}
+
+// decide lets the player fold, check or call, or raise by the size on the
+// slider, with the arrow keys or a letter for each button
+func (t *tui) decide(ctx context.Context, g *game, seat int) (decision, error) {
+	t.turn = seat
+	defer func() {
+		t.turn, t.bar = -1, nil
+	}()
+
+	buttons := []string{"Fold", "Check"}
+	if amount := g.toCall(seat); amount > 0 {
+		buttons[1] = fmt.Sprintf("Call %d", amount)
+	}
+	minRaise, maxRaise, err := g.raiseBounds(seat)
+	canRaise := err == nil
+	if canRaise {
+		buttons = append(buttons, "Raise")
+	}
+
+	chosen, amount := 1, minRaise
+	for {
+		t.bar = t.actionBar(ctx, buttons, chosen, amount, minRaise, maxRaise)
+		t.draw()
+
+		// Wake every second to count the clock down
+		wait, cancel := context.WithTimeout(ctx, time.Second)
+		key, err := t.keys.readLine(wait)
+		cancel()
+		switch {
+		case err == context.DeadlineExceeded && ctx.Err() == nil:
+			continue
+		case err == io.EOF:
+			return decision{action: fold}, nil
+		case err != nil:
+			return decision{}, err
+		}
+
+		sized := false
+		switch key {
+		case "left":
+			chosen = (chosen + len(buttons) - 1) % len(buttons)
+		case "right", "tab":
+			chosen = (chosen + 1) % len(buttons)
+		case "f", "F":
+			chosen = 0
+		case "c", "C", "k", "K":
+			chosen = 1
+		case "r", "R", "b", "B":
+			if canRaise {
+				chosen = 2
+			}
+		case "up", "+", "=":
+			amount, sized = amount+g.bigBlind, true
+		case "down", "-":
+			amount, sized = amount-g.bigBlind, true
+		case "pgup":
+			amount, sized = amount+10*g.bigBlind, true
+		case "pgdn":
+			amount, sized = amount-10*g.bigBlind, true
+		case "home":
+			amount, sized = minRaise, true
+		case "end", "a", "A":
+			amount, sized = maxRaise, true
+		case "eof":
+			return decision{action: fold}, nil
+		case "enter":
+			switch chosen {
+			case 0:
+				return decision{action: fold}, nil
+			case 1:
+				return decision{action: call}, nil
+			default:
+				return decision{action: raise, amount: amount}, nil
+			}
+		}
+
+		// Sizing the bet means raising
+		if sized && canRaise {
+			chosen = 2
+		}
+		amount = max(minRaise, min(amount, maxRaise))
+	}
+}
+
+// The buttons, with the chosen one lit, and the bet slider
+func (t *tui) actionBar(ctx context.Context, buttons []string, chosen, amount, minRaise, maxRaise int) []string {
+	hints := "Your turn" + timeToAct(ctx) + ": ←/→ choose, Enter to act"
+	var row strings.Builder
+	for i, label := range buttons {
+		if i == 2 {
+			if t.g.highestBet() == 0 {
+				label = fmt.Sprintf("Bet %d", amount)
+			} else {
+				label = fmt.Sprintf("Raise to %d", amount)
+			}
+		}
+		if i == chosen {
+			row.WriteString(ansiReverse + " " + label + " " + ansiReset + " ")
+		} else {
+			row.WriteString(" " + label + "  ")
+		}
+	}
+
+	if len(buttons) > 2 && minRaise < maxRaise {
+		hints += ", ↑/↓ size the bet, Home/End for the least and the most"
+		const notches = 20
+		at := (amount - minRaise) * (notches - 1) / (maxRaise - minRaise)
+		fmt.Fprintf(&row, "  %d ◀%s●%s▶ %d", minRaise, strings.Repeat("━", at), strings.Repeat("━", notches-1-at), maxRaise)
+	}
+	return []string{ansiBold + hints + ansiReset, row.String()}
+}
+
+// draw redraws the whole screen in place
+func (t *tui) draw() {
+	var b strings.Builder
+	b.WriteString("\x1b[?25l\x1b[H")
+	for i, line := range t.screen() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line + ansiReset + "\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	if t.editing {
+		b.WriteString("\x1b[?25h")
+	}
+	io.WriteString(t.term, b.String())
+}
+
+// The lines of the screen from top to bottom
+func (t *tui) screen() []string {
+	g := t.g
+	width := max(t.width, 72)
+	title := fmt.Sprintf(" Five-card draw, %s, blinds %d/%d", g.betting.structure, g.smallBlind, g.bigBlind)
+	if g.ante > 0 {
+		title += fmt.Sprintf(", ante %d", g.ante)
+	}
+	if g.wilds.jokers > 0 || g.wilds.deucesWild {
+		title += ", " + g.wilds.String()
+	}
+	lines := append([]string{ansiBold + title + ansiReset, ""}, t.table(width)...)
+	lines = append(lines, "", ansiDim+strings.Repeat("─", width)+ansiReset)
+
+	bar := t.bar
+	if bar == nil {
+		bar = []string{"", ""}
+	}
+	log := t.log
+	if t.partial != "" && !t.editing {
+		log = append(log[:len(log):len(log)], t.partial)
+	}
+	rows := max(t.height-len(lines)-len(bar)-1, 3)
+	if len(log) > rows {
+		log = log[len(log)-rows:]
+	}
+	for _, line := range log {
+		lines = append(lines, truncate(line, width))
+	}
+	for i := len(log); i < rows; i++ {
+		lines = append(lines, "")
+	}
+	lines = append(lines, ansiDim+strings.Repeat("─", width)+ansiReset)
+	return append(lines, bar...)
+}
+
+// Places around the table, clockwise from the bottom middle, as row and column
+var tableSlots = [][2]int{{2, 1}, {2, 0}, {1, 0}, {0, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}}
+
+// Which of the places a table of each size uses, spread around it
+var slotsFor = [][]int{
+	1: {0},
+	2: {0, 4},
+	3: {0, 3, 5},
+	4: {0, 2, 4, 6},
+	5: {0, 1, 3, 5, 7},
+	6: {0, 1, 3, 4, 5, 7},
+	7: {0, 1, 2, 3, 5, 6, 7},
+	8: {0, 1, 2, 3, 4, 5, 6, 7},
+}
+
+// table lays the seats out around the felt, with the player at the bottom
+func (t *tui) table(width int) []string {
+	g := t.g
+	you := g.humanSeat()
+	bottom := max(you, 0)
+	column := width / 3
+
+	var grid [3][3][]string
+	grid[1][1] = t.felt(column - 2)
+	slots := slotsFor[min(len(g.players), len(slotsFor)-1)]
+	for k, slot := range slots {
+		at := tableSlots[slot]
+		grid[at[0]][at[1]] = t.seat((bottom+k)%len(g.players), you)
+	}
+
+	var lines []string
+	for _, row := range grid {
+		for i := 0; i < seatLines; i++ {
+			var line strings.Builder
+			for _, block := range row {
+				text := ""
+				if i < len(block) {
+					text = block[i]
+				}
+				line.WriteString(centre(text, column))
+			}
+			lines = append(lines, line.String())
+		}
+	}
+	return lines
+}
+
+// Lines in a seat, and in the felt
+const seatLines = 4
+
+// seat draws a player: their name and whether they have the button or the
+// turn, their stack, their cards and their bet
+func (t *tui) seat(i, you int) []string {
+	g := t.g
+	p := g.players[i]
+	name := p.name
+	if i == t.turn {
+		name = ansiBold + "▶ " + name + ansiReset
+	}
+	if i == g.dealer {
+		name += " " + ansiButton + " D " + ansiReset
+	}
+
+	var cards, status string
+	switch {
+	case p.sittingOut:
+		status = "sitting out"
+	case p.chips == 0 && p.total == 0 && (p.folded || len(p.hand) == 0):
+		status = ansiDim + "out" + ansiReset
+	case len(p.hand) == 0:
+		// Between hands
+	case p.folded:
+		status = ansiDim + "folded" + ansiReset
+	default:
+		glyphs := make([]string, len(p.hand))
+		for j, c := range p.hand {
+			glyphs[j] = cardBack
+			if i == you || g.revealed {
+				glyphs[j] = cardGlyph(c)
+			}
+		}
+		cards = strings.Join(glyphs, " ")
+		if p.bet > 0 {
+			status = fmt.Sprintf("bet %d", p.bet)
+		}
+		if p.chips == 0 {
+			status = strings.TrimPrefix(status+", all in", ", ")
+		}
+	}
+	return []string{name, fmt.Sprintf("%d chips", p.chips), cards, status}
+}
+
+// felt draws the middle of the table with the pot on it
+func (t *tui) felt(width int) []string {
+	g := t.g
+	inner := width - 2
+	edge := func(left, right string) string {
+		return ansiFelt + left + strings.Repeat("─", inner) + right + ansiReset
+	}
+	side := func(text string) string {
+		return ansiFelt + "│" + ansiReset + centre(text, inner) + ansiFelt + "│" + ansiReset
+	}
+	return []string{
+		edge("╭", "╮"),
+		side(fmt.Sprintf("Pot %d", g.pot)),
+		side(g.round),
+		edge("╰", "╯"),
+	}
+}
+
+// Width of a line on screen, leaving out its escape sequences
+func visibleWidth(s string) int {
+	width, escaped := 0, false
+	for _, r := range s {
+		switch {
+		case r == 0x1b:
+			escaped = true
+		case escaped:
+			escaped = r < 0x40 || r > 0x7e || r == '['
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// Pad a line on both sides to a width
+func centre(s string, width int) string {
+	gap := max(width-visibleWidth(s), 0)
+	return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+}
+
+// Cut plain text down to a width
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScanKeys(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("\x1b[Ab\u00e9\r\x1b[6~\x7f\x1b[99z\x1bOD"))
+	scanner.Split(scanKeys)
+	var keys []string
+	for scanner.Scan() {
+		keys = append(keys, scanner.Text())
+	}
+	want := []string{"up", "b", "\u00e9", "enter", "pgdn", "backspace", "", "left"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected keys %q, but got %q", want, keys)
+	}
+}
+
+func TestCardGlyphs(t *testing.T) {
+	tests := []struct {
+		card, colour, glyph string
+	}{
+		{"Ten of Hearts", ansiRed, "10♥"},
+		{"Queen of Diamonds", ansiRed, " Q♦"},
+		{"Ace of Spades", ansiBlack, " A♠"},
+		{"Two of Clubs", ansiBlack, " 2♣"},
+		{jokerCard, ansiJoker, "Jk★"},
+	}
+	for _, test := range tests {
+		if got, want := cardGlyph(test.card), test.colour+test.glyph+ansiReset; got != want {
+			t.Errorf("Expected %s to be drawn as %q, but got %q", test.card, want, got)
+		}
+	}
+	if w := visibleWidth(cardGlyph("Ten of Hearts")); w != 3 {
+		t.Errorf("Expected a card to take 3 columns, but it takes %d", w)
+	}
+}
+
+// A game on a full-screen table, drawn to a buffer and played with the keys given
+func tableWith(keys string) (*game, *tui, *bytes.Buffer) {
+	var term bytes.Buffer
+	g := newGame()
+	g.addOpponents(3)
+	screen := newTUI(g, &term, strings.NewReader(keys))
+	g.screen, g.out = screen, screen
+	return g, screen, &term
+}
+
+func TestTUIDrawsTheTable(t *testing.T) {
+	g, screen, _ := tableWith("")
+	g.postBlinds()
+	g.dealHands()
+	screen.turn = 0
+
+	drawn := strings.Join(screen.screen(), "\n")
+	for _, c := range g.players[0].hand {
+		if !strings.Contains(drawn, cardGlyph(c)) {
+			t.Errorf("Expected your %s to be face up", c)
+		}
+	}
+	if backs := strings.Count(drawn, cardBack); backs != 15 {
+		t.Errorf("Expected the three computers' 15 cards face down, but %d are", backs)
+	}
+	for _, want := range []string{"▶ You", "Pot 75", "bet 50", "Computer 3", " D "} {
+		if !strings.Contains(drawn, want) {
+			t.Errorf("Expected the table to show %q:\n%s", want, drawn)
+		}
+	}
+
+	// You sit at the bottom, the computers around the top
+	lines := screen.table(80)
+	if !strings.Contains(lines[len(lines)-seatLines], "You") || !strings.Contains(lines[0], "Computer 2") {
+		t.Errorf("Expected you at the bottom and Computer 2 across the table:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestTUIButtonsAndSlider(t *testing.T) {
+	tests := []struct {
+		keys string
+		want decision
+	}{
+		{"\r", decision{action: call}},
+		{"\x1b[D\r", decision{action: fold}},
+		{"f\x1b[C\x1b[C\r", decision{action: raise, amount: 100}},
+		{"\x1b[A\x1b[A\r", decision{action: raise, amount: 200}},
+		{"\x1b[F\x1b[B\r", decision{action: raise, amount: 950}},
+		{"r\x1b[6~\x1b[H\r", decision{action: raise, amount: 100}},
+		{"", decision{action: fold}},
+	}
+	for _, test := range tests {
+		g, screen, _ := tableWith(test.keys)
+		g.postBlinds()
+		g.dealHands()
+		g.lastRaise = g.bigBlind
+		seat := g.firstToAct()
+		d, err := screen.decide(context.Background(), g, seat)
+		if err != nil || d != test.want {
+			t.Errorf("Expected keys %q to give %v, but got %v, %v", test.keys, test.want, d, err)
+		}
+		if screen.turn != -1 || screen.bar != nil {
+			t.Errorf("Expected the turn to be over after acting")
+		}
+	}
+}
+
+func TestTUIReadsALine(t *testing.T) {
+	g, screen, term := tableWith("13x\x7f5\r")
+	src := &lineSource{edit: screen.readLine}
+	g.out.Write([]byte("Discard which cards? "))
+
+	line, err := src.readLine(context.Background())
+	if line != "135" || err != nil {
+		t.Errorf("Expected the line typed, less the key rubbed out, but got %q, %v", line, err)
+	}
+	if last := screen.log[len(screen.log)-1]; last != "Discard which cards? 135" {
+		t.Errorf("Expected the prompt and the answer in the log, but got %q", last)
+	}
+	if !strings.Contains(term.String(), "Discard which cards? 13x") {
+		t.Errorf("Expected the line to be shown as it was typed")
+	}
+}
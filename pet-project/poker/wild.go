@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How a joker is written in a deck and which suit it parses to
+const (
+	jokerCard = "Joker"
+	jokerSuit = "Joker"
+)
+
+// wildRules describes the jokers and wild cards a table plays with
+type wildRules struct {
+	jokers     int  // jokers added to the deck
+	deucesWild bool // every Two can stand in for any card
+	bug        bool // jokers may only be used as an Ace or to complete a straight or flush
+}
+
+// newDeckWithJokers returns a standard deck with the given number of jokers added
+func newDeckWithJokers(jokers int) deck {
+	cards := newDeck()
+	for i := 0; i < jokers; i++ {
+		cards = append(cards, jokerCard)
+	}
+	return cards
+}
+
+func (c card) isJoker() bool {
+	return c.suit == jokerSuit
+}
+
+// isWild reports whether a card may be substituted under these rules
+func (w wildRules) isWild(c card) bool {
+	return c.isJoker() || (w.deucesWild && c.value == 2)
+}
+
+func (w wildRules) String() string {
+	var parts []string
+	if w.deucesWild {
+		parts = append(parts, "deuces wild")
+	}
+	if w.jokers > 0 {
+		kind := "wild"
+		if w.bug {
+			kind = "bug"
+		}
+		plural := ""
+		if w.jokers > 1 {
+			plural = "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s joker%s", w.jokers, kind, plural))
+	}
+	if len(parts) == 0 {
+		return "no wild cards"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Evaluate a hand using the table's wild rules
+func (g *game) evaluate(hand deck) handRank {
+	return evaluateWildHand(hand.toCards(), g.wilds)
+}
+
+// evaluateWildHand picks the best substitution for every wild card in the hand.
+// Without wild cards it is the same as evaluateHand.
+func evaluateWildHand(cards []card, rules wildRules) handRank {
+	var naturals []card
+	var wilds []card
+	for _, c := range cards {
+		if rules.isWild(c) {
+			wilds = append(wilds, c)
+		} else {
+			naturals = append(naturals, c)
+		}
+	}
+
+	if len(wilds) == 0 {
+		return evaluateHand(cards)
+	}
+
+	// Nothing natural to build around, so every wild becomes an Ace
+	if len(naturals) == 0 {
+		return handRank{rank: 11, rankName: "Five of a Kind", values: []int{14}}
+	}
+
+	// Suits only matter for flushes, which need every natural card in one suit
+	suit := naturals[0].suit
+	for _, c := range naturals {
+		if c.suit != suit {
+			suit = "Spades"
+			break
+		}
+	}
+
+	hand := make([]card, len(cards))
+	var best handRank
+	found := false
+
+	var substitute func(i int)
+	substitute = func(i int) {
+		if i == len(wilds) {
+			copy(hand, naturals)
+			for j, w := range wilds {
+				hand[len(naturals)+j] = card{value: w.value, suit: suit}
+			}
+			rank := evaluateHand(hand)
+			if !bugAllowed(wilds, rules, rank) || isFlush(rank) && hasDuplicate(hand) {
+				return
+			}
+			if !found || compareHands(rank, best) > 0 {
+				best = rank
+				found = true
+			}
+			return
+		}
+		for value := 14; value >= 2; value-- {
+			wilds[i].value = value
+			substitute(i + 1)
+		}
+	}
+	substitute(0)
+
+	return best
+}
+
+// isFlush reports whether a rank needs its five cards in one suit
+func isFlush(rank handRank) bool {
+	switch rank.rank {
+	case 6, 9, 10: // Flush, Straight Flush, Royal Flush
+		return true
+	}
+	return false
+}
+
+// hasDuplicate reports whether a card is in the hand twice, as when a wild
+// card stands in for one the hand already holds. A flush can't be made that
+// way, since its suit only has one of each card.
+func hasDuplicate(hand []card) bool {
+	seen := make(map[card]bool)
+	for _, c := range hand {
+		if seen[c] {
+			return true
+		}
+		seen[c] = true
+	}
+	return false
+}
+
+// bugAllowed checks that any bug joker is used as an Ace or to complete a straight or flush
+func bugAllowed(substituted []card, rules wildRules, rank handRank) bool {
+	if !rules.bug {
+		return true
+	}
+	switch rank.rank {
+	case 5, 6, 9, 10: // Straight, Flush, Straight Flush, Royal Flush
+		return true
+	}
+	for _, c := range substituted {
+		if c.isJoker() && c.value != 14 {
+			return false
+		}
+	}
+	return true
+}